@@ -8,6 +8,13 @@ import (
 	"strings"
 )
 
+type (
+	setterMethod struct {
+		method reflect.Method
+		keyed  bool
+	}
+)
+
 var (
 	numberSubPath = regexp.MustCompile(`\[([0-9]+)\]`)
 )
@@ -46,58 +53,12 @@ func set(source reflect.Value, path []string, value reflect.Value) bool {
 	}
 
 	parent = rawValue(parent)
-	parentType := parent.Type()
-	parentKind := basicKind(parent)
-
-	if parentType.Implements(anyType) {
-		call := func(reflectMethod reflect.Method, args []reflect.Value) bool {
-			numIn := reflectMethod.Type.NumIn()
-			if numIn == len(args) {
-				for i := 0; i < numIn; i++ {
-					if !args[i].Type().AssignableTo(reflectMethod.Type.In(i)) {
-						return false
-					}
-				}
-				out := reflectMethod.Func.Call(args)
-				if len(out) == 0 {
-					return true
-				} else if len(out) > 0 {
-					okOrErr := out[len(out)-1].Interface()
-					if ok, subOk := okOrErr.(bool); subOk && ok {
-						return true
-					} else if err, subOk := okOrErr.(error); subOk && IsNil(err) {
-						return true
-					}
-				}
-			}
-			return false
-		}
-
-		for i := 0; i < parentType.NumMethod(); i++ {
-			reflectMethod := parentType.Method(i)
-			if !reflectMethod.IsExported() {
-				continue
-			}
-			if strcase.ToLowerCamel(reflectMethod.Name) == "set"+strcase.ToCamel(current) {
-				if ok := call(reflectMethod, []reflect.Value{parent, value}); ok {
-					return true
-				}
-			}
-		}
-		for i := 0; i < parentType.NumMethod(); i++ {
-			reflectMethod := parentType.Method(i)
-			if !reflectMethod.IsExported() {
-				continue
-			}
-			name := strcase.ToLowerCamel(reflectMethod.Name)
-			if name == "set" || name == "store" || name == "save" || name == "put" {
-				if ok := call(reflectMethod, []reflect.Value{parent, reflect.ValueOf(current), value}); ok {
-					return true
-				}
-			}
-		}
+	if setByMethod(parent, current, value) {
+		return true
 	}
 
+	parentType := parent.Type()
+	parentKind := basicKind(parent)
 	if parentKind == pointerKind {
 		parent = parent.Elem()
 		parentType = parent.Type()
@@ -147,6 +108,9 @@ func get(source reflect.Value, path []string) ([]reflect.Value, bool) {
 
 	originSource := source
 	source = rawValue(source)
+	if !source.IsValid() {
+		return nil, false
+	}
 	sourceType := source.Type()
 	sourceKind := basicKind(source)
 
@@ -158,65 +122,8 @@ func get(source reflect.Value, path []string) ([]reflect.Value, bool) {
 		return append(his, originSource), true
 	}
 
-	if sourceType.Implements(anyType) {
-		call := func(reflectMethod reflect.Method, args []reflect.Value) (reflect.Value, bool) {
-			numIn := reflectMethod.Type.NumIn()
-			numOut := reflectMethod.Type.NumOut()
-			if numIn == len(args) && numOut > 0 {
-				for i := 0; i < numIn; i++ {
-					if !args[i].Type().ConvertibleTo(reflectMethod.Type.In(i)) {
-						return reflect.Value{}, false
-					}
-				}
-				out := reflectMethod.Func.Call(args)
-				if len(out) == 1 {
-					return out[0], true
-				}
-
-				okOrErr := out[len(out)-1].Interface()
-				var result reflect.Value
-				if len(out) == 2 {
-					result = out[0]
-				} else {
-					var results []any
-					for i := 0; i < len(out)-1; i++ {
-						results = append(results, out[i].Interface())
-					}
-					result = reflect.ValueOf(results)
-				}
-
-				if ok, subOk := okOrErr.(bool); subOk && ok {
-					return result, true
-				} else if err, subOk := okOrErr.(error); subOk && IsNil(err) {
-					return result, true
-				}
-			}
-			return reflect.Value{}, false
-		}
-
-		for i := 0; i < sourceType.NumMethod(); i++ {
-			reflectMethod := sourceType.Method(i)
-			if !reflectMethod.IsExported() {
-				continue
-			}
-			if strcase.ToLowerCamel(reflectMethod.Name) == current {
-				if r, ok := call(reflectMethod, []reflect.Value{source}); ok {
-					return resolve(r)
-				}
-			}
-		}
-		for i := 0; i < sourceType.NumMethod(); i++ {
-			reflectMethod := sourceType.Method(i)
-			if !reflectMethod.IsExported() {
-				continue
-			}
-			name := strcase.ToLowerCamel(reflectMethod.Name)
-			if name == "get" || name == "load" {
-				if r, ok := call(reflectMethod, []reflect.Value{source, reflect.ValueOf(current)}); ok {
-					return resolve(r)
-				}
-			}
-		}
+	if v, ok := getByMethod(source, current); ok {
+		return resolve(v)
 	}
 
 	switch sourceKind {
@@ -251,3 +158,138 @@ func get(source reflect.Value, path []string) ([]reflect.Value, bool) {
 
 	return nil, false
 }
+
+// getByMethod gets key through a getter of source, Key() or a map like Get or Load(key).
+func getByMethod(source reflect.Value, key string) (reflect.Value, bool) {
+	call := func(reflectMethod reflect.Method, args []reflect.Value) (reflect.Value, bool) {
+		numIn := reflectMethod.Type.NumIn()
+		numOut := reflectMethod.Type.NumOut()
+		if numIn == len(args) && numOut > 0 {
+			for i := 0; i < numIn; i++ {
+				if !args[i].Type().ConvertibleTo(reflectMethod.Type.In(i)) {
+					return reflect.Value{}, false
+				}
+			}
+			out := reflectMethod.Func.Call(args)
+			if len(out) == 1 {
+				return out[0], true
+			}
+
+			okOrErr := out[len(out)-1].Interface()
+			var result reflect.Value
+			if len(out) == 2 {
+				result = out[0]
+			} else {
+				var results []any
+				for i := 0; i < len(out)-1; i++ {
+					results = append(results, out[i].Interface())
+				}
+				result = reflect.ValueOf(results)
+			}
+
+			if ok, subOk := okOrErr.(bool); subOk && ok {
+				return result, true
+			} else if err, subOk := okOrErr.(error); subOk && IsNil(err) {
+				return result, true
+			}
+		}
+		return reflect.Value{}, false
+	}
+
+	sourceType := source.Type()
+	for i := 0; i < sourceType.NumMethod(); i++ {
+		reflectMethod := sourceType.Method(i)
+		if !reflectMethod.IsExported() {
+			continue
+		}
+		if strcase.ToLowerCamel(reflectMethod.Name) == key {
+			if r, ok := call(reflectMethod, []reflect.Value{source}); ok {
+				return r, true
+			}
+		}
+	}
+	for i := 0; i < sourceType.NumMethod(); i++ {
+		reflectMethod := sourceType.Method(i)
+		if !reflectMethod.IsExported() {
+			continue
+		}
+		name := strcase.ToLowerCamel(reflectMethod.Name)
+		if name == "get" || name == "load" {
+			if r, ok := call(reflectMethod, []reflect.Value{source, reflect.ValueOf(key)}); ok {
+				return r, true
+			}
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// setByMethod sets key through a setter of source, SetKey(value) or a map like Set, Store, Save or Put(key, value).
+func setByMethod(source reflect.Value, key string, value reflect.Value) bool {
+	for _, setter := range setters(source.Type(), key) {
+		args := []reflect.Value{source, value}
+		if setter.keyed {
+			args = []reflect.Value{source, reflect.ValueOf(key), value}
+		}
+
+		numIn := setter.method.Type.NumIn()
+		if numIn != len(args) {
+			continue
+		}
+		assignable := true
+		for i := 0; i < numIn; i++ {
+			if !args[i].Type().AssignableTo(setter.method.Type.In(i)) {
+				assignable = false
+			}
+		}
+		if !assignable {
+			continue
+		}
+
+		out := setter.method.Func.Call(args)
+		if len(out) == 0 {
+			return true
+		}
+		okOrErr := out[len(out)-1].Interface()
+		if ok, subOk := okOrErr.(bool); subOk && ok {
+			return true
+		} else if err, subOk := okOrErr.(error); subOk && IsNil(err) {
+			return true
+		}
+	}
+	return false
+}
+
+// setterType tells the type of the value the first setter of key on sourceType takes.
+func setterType(sourceType reflect.Type, key string) (reflect.Type, bool) {
+	for _, setter := range setters(sourceType, key) {
+		numIn := setter.method.Type.NumIn()
+		if setter.keyed && numIn == 3 && reflect.TypeOf(key).AssignableTo(setter.method.Type.In(1)) {
+			return setter.method.Type.In(2), true
+		}
+		if !setter.keyed && numIn == 2 {
+			return setter.method.Type.In(1), true
+		}
+	}
+	return nil, false
+}
+
+func setters(sourceType reflect.Type, key string) []setterMethod {
+	var res []setterMethod
+	for i := 0; i < sourceType.NumMethod(); i++ {
+		reflectMethod := sourceType.Method(i)
+		if reflectMethod.IsExported() && strcase.ToLowerCamel(reflectMethod.Name) == "set"+strcase.ToCamel(key) {
+			res = append(res, setterMethod{method: reflectMethod})
+		}
+	}
+	for i := 0; i < sourceType.NumMethod(); i++ {
+		reflectMethod := sourceType.Method(i)
+		if !reflectMethod.IsExported() {
+			continue
+		}
+		name := strcase.ToLowerCamel(reflectMethod.Name)
+		if name == "set" || name == "store" || name == "save" || name == "put" {
+			res = append(res, setterMethod{method: reflectMethod, keyed: true})
+		}
+	}
+	return res
+}
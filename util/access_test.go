@@ -45,6 +45,11 @@ func TestGet(t *testing.T) {
 			expectResult: 1,
 			expectOk:     true,
 		},
+		{
+			whenSource: map[string]any{"k1": nil},
+			whenKey:    "k1.k2",
+			expectOk:   false,
+		},
 	}
 
 	for _, tc := range testCases {
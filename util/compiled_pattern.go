@@ -0,0 +1,100 @@
+package util
+
+import (
+	"container/list"
+	"sync"
+)
+
+type (
+	Pattern struct {
+		pattern  string
+		variants []pathVariant
+		matcher  *PathMatcher[struct{}]
+	}
+
+	patternCache struct {
+		mu       sync.Mutex
+		capacity int
+		entries  map[string]*list.Element
+		order    *list.List
+	}
+)
+
+// patterns keeps the patterns compiled by MatchPath, evicting the least recently used.
+var patterns = newPatternCache(256)
+
+func CompilePattern(pattern string) (*Pattern, error) {
+	variants, err := parsePattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	matcher := NewPathMatcher[struct{}]()
+	for _, v := range variants {
+		matcher.add(pattern, v, struct{}{}, nil)
+	}
+	return &Pattern{
+		pattern:  pattern,
+		variants: variants,
+		matcher:  matcher,
+	}, nil
+}
+
+func (p *Pattern) Match(path string) (bool, map[string]string) {
+	_, res, params := p.matcher.Match(path)
+	if res != p.pattern {
+		return false, nil
+	}
+	return true, params
+}
+
+func (p *Pattern) Build(params map[string]string) (string, error) {
+	return buildPath(p.pattern, p.variants, params)
+}
+
+func (p *Pattern) ParamNames() []string {
+	return p.variants[0].paramNames()
+}
+
+func (p *Pattern) String() string {
+	return p.pattern
+}
+
+func newPatternCache(capacity int) *patternCache {
+	return &patternCache{
+		capacity: capacity,
+		entries:  map[string]*list.Element{},
+		order:    list.New(),
+	}
+}
+
+func (c *patternCache) compile(pattern string) (*Pattern, error) {
+	c.mu.Lock()
+	if e, ok := c.entries[pattern]; ok {
+		c.order.MoveToFront(e)
+		c.mu.Unlock()
+		return e.Value.(*Pattern), nil
+	}
+	c.mu.Unlock()
+
+	// Compile outside of the lock, when another goroutine compiles the same pattern the first one stored is kept.
+	p, err := CompilePattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[pattern]; ok {
+		c.order.MoveToFront(e)
+		return e.Value.(*Pattern), nil
+	}
+	c.entries[pattern] = c.order.PushFront(p)
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*Pattern).pattern)
+	}
+	return p, nil
+}
@@ -0,0 +1,84 @@
+package util
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+)
+
+func TestCompilePattern(t *testing.T) {
+	p, err := CompilePattern("/users/:id<[0-9]+>(/:tab)")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "/users/:id<[0-9]+>(/:tab)", p.String())
+	assert.Equal(t, []string{"id", "tab"}, p.ParamNames())
+
+	ok, params := p.Match("/users/1/posts")
+	assert.True(t, ok)
+	assert.Equal(t, map[string]string{"id": "1", "tab": "posts"}, params)
+
+	ok, params = p.Match("/users/me")
+	assert.False(t, ok)
+	assert.Nil(t, params)
+
+	path, err := p.Build(map[string]string{"id": "1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "/users/1", path)
+
+	_, err = CompilePattern("/users/(:id")
+	assert.ErrorIs(t, err, ErrInvalidPattern)
+}
+
+func TestPatternCache(t *testing.T) {
+	c := newPatternCache(2)
+
+	a, _ := c.compile("/a")
+	c.compile("/b")
+
+	cached, _ := c.compile("/a")
+	assert.Same(t, a, cached)
+
+	// "/b" is the least recently used.
+	c.compile("/c")
+	assert.Equal(t, 2, c.order.Len())
+	assert.Contains(t, c.entries, "/a")
+	assert.NotContains(t, c.entries, "/b")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		i := i
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				p, err := c.compile(fmt.Sprintf("/%d/:id", (i+j)%4))
+				assert.NoError(t, err)
+				ok, _ := p.Match(fmt.Sprintf("/%d/1", (i+j)%4))
+				assert.True(t, ok)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.LessOrEqual(t, c.order.Len(), 2)
+}
+
+func BenchmarkMatchPath(b *testing.B) {
+	pattern := "/users/:id/posts/:post"
+	path := "/users/1/posts/2"
+
+	b.Run("compile", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			p, _ := CompilePattern(pattern)
+			p.Match(path)
+		}
+	})
+	b.Run("cached", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			MatchPath(pattern, path)
+		}
+	})
+}
@@ -0,0 +1,121 @@
+package util
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+type (
+	ConcurrentPathMatcher[T any] struct {
+		matcher atomic.Value
+		mu      sync.Mutex
+	}
+)
+
+func NewConcurrentPathMatcher[T any](options ...PathMatcherOption) *ConcurrentPathMatcher[T] {
+	m := &ConcurrentPathMatcher[T]{}
+	m.matcher.Store(NewPathMatcher[T](options...))
+	return m
+}
+
+func (m *ConcurrentPathMatcher[T]) Add(path string, value T, options ...RouteOption) {
+	m.update(func(matcher *PathMatcher[T]) bool {
+		matcher.Add(path, value, options...)
+		return true
+	})
+}
+
+func (m *ConcurrentPathMatcher[T]) AddE(path string, value T, options ...RouteOption) error {
+	var err error
+	m.update(func(matcher *PathMatcher[T]) bool {
+		err = matcher.AddE(path, value, options...)
+		return err == nil
+	})
+	return err
+}
+
+func (m *ConcurrentPathMatcher[T]) Remove(path string, options ...RouteOption) bool {
+	return m.update(func(matcher *PathMatcher[T]) bool {
+		return matcher.Remove(path, options...)
+	})
+}
+
+func (m *ConcurrentPathMatcher[T]) Mount(prefix string, other *PathMatcher[T]) error {
+	var err error
+	m.update(func(matcher *PathMatcher[T]) bool {
+		err = matcher.Mount(prefix, other)
+		return err == nil
+	})
+	return err
+}
+
+func (m *ConcurrentPathMatcher[T]) Unmount(prefix string) int {
+	var count int
+	m.update(func(matcher *PathMatcher[T]) bool {
+		count = matcher.Unmount(prefix)
+		return count > 0
+	})
+	return count
+}
+
+func (m *ConcurrentPathMatcher[T]) Match(path string) (T, string, map[string]string) {
+	return m.load().Match(path)
+}
+
+func (m *ConcurrentPathMatcher[T]) MatchPrefix(path string) (T, string, map[string]string, string) {
+	return m.load().MatchPrefix(path)
+}
+
+func (m *ConcurrentPathMatcher[T]) MatchRequest(r *http.Request) (T, string, map[string]string) {
+	return m.load().MatchRequest(r)
+}
+
+func (m *ConcurrentPathMatcher[T]) MatchInto(path string, dst *Params) (T, string) {
+	return m.load().MatchInto(path, dst)
+}
+
+func (m *ConcurrentPathMatcher[T]) MatchAll(path string) []PathMatch[T] {
+	return m.load().MatchAll(path)
+}
+
+func (m *ConcurrentPathMatcher[T]) Canonical(path string) (string, bool) {
+	return m.load().Canonical(path)
+}
+
+func (m *ConcurrentPathMatcher[T]) Build(path string, params map[string]string) (string, error) {
+	return m.load().Build(path, params)
+}
+
+func (m *ConcurrentPathMatcher[T]) Suggest(path string, n int) []string {
+	return m.load().Suggest(path, n)
+}
+
+func (m *ConcurrentPathMatcher[T]) Routes() []string {
+	return m.load().Routes()
+}
+
+func (m *ConcurrentPathMatcher[T]) Walk(fn func(pattern string, paramNames []string) bool) {
+	m.load().Walk(fn)
+}
+
+func (m *ConcurrentPathMatcher[T]) String() string {
+	return m.load().String()
+}
+
+func (m *ConcurrentPathMatcher[T]) load() *PathMatcher[T] {
+	return m.matcher.Load().(*PathMatcher[T])
+}
+
+// update applies fn to a copy of the current tree and publishes it, so readers keep using the old snapshot until then.
+func (m *ConcurrentPathMatcher[T]) update(fn func(matcher *PathMatcher[T]) bool) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	next := m.load().clone()
+	if !fn(next) {
+		return false
+	}
+	m.matcher.Store(next)
+	return true
+}
@@ -0,0 +1,67 @@
+package util
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+)
+
+func TestConcurrentPathMatcher_Match(t *testing.T) {
+	m := NewConcurrentPathMatcher[string]()
+
+	m.Add("/static", "static")
+	m.Add("/params/:foo", "params")
+
+	value, path, param := m.Match("/params/1")
+	assert.Equal(t, "params", value)
+	assert.Equal(t, "/params/:foo", path)
+	assert.Equal(t, map[string]string{"foo": "1"}, param)
+
+	res, err := m.Build("/params/:foo", map[string]string{"foo": "2"})
+	assert.NoError(t, err)
+	assert.Equal(t, "/params/2", res)
+
+	assert.True(t, m.Remove("/params/:foo"))
+	assert.False(t, m.Remove("/params/:foo"))
+
+	_, path, _ = m.Match("/params/1")
+	assert.Equal(t, "", path)
+
+	value, path, _ = m.Match("/static")
+	assert.Equal(t, "static", value)
+	assert.Equal(t, "/static", path)
+}
+
+func TestConcurrentPathMatcher_Race(t *testing.T) {
+	m := NewConcurrentPathMatcher[int]()
+	m.Add("/static", 0)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		i := i
+
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				path := fmt.Sprintf("/%d/%d/:id", i, j)
+				m.Add(path, j)
+				m.Remove(path)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				value, path, _ := m.Match("/static")
+				assert.Equal(t, 0, value)
+				assert.Equal(t, "/static", path)
+				m.Match(fmt.Sprintf("/%d/%d/1", i, j))
+			}
+		}()
+	}
+	wg.Wait()
+
+	_, path, _ := m.Match("/0/0/1")
+	assert.Equal(t, "", path)
+}
@@ -0,0 +1,233 @@
+package util
+
+import (
+	"strings"
+)
+
+type (
+	HostMatcher[T any] struct {
+		hosts  *PathMatcher[T]
+		routes *PathMatcher[T]
+	}
+)
+
+// hostBoundary separates the port from the host labels, and the host labels from the path, in the keys of routes.
+const hostBoundary = "/\x00"
+
+// anyPort stands for the port of a host pattern without one, it is left out of the params.
+const anyPort = ""
+
+func NewHostMatcher[T any]() *HostMatcher[T] {
+	hosts := NewPathMatcher[T]()
+	hosts.options.segmentParams = true
+
+	return &HostMatcher[T]{
+		hosts:  hosts,
+		routes: NewPathMatcher[T](),
+	}
+}
+
+func (m *HostMatcher[T]) Add(host string, value T) {
+	if err := m.add(host, "", value, false); err != nil {
+		panic(err)
+	}
+}
+
+func (m *HostMatcher[T]) AddE(host string, value T) error {
+	return m.add(host, "", value, true)
+}
+
+func (m *HostMatcher[T]) AddRoute(host string, path string, value T) {
+	if err := m.add(host, path, value, false); err != nil {
+		panic(err)
+	}
+}
+
+func (m *HostMatcher[T]) AddRouteE(host string, path string, value T) error {
+	return m.add(host, path, value, true)
+}
+
+func (m *HostMatcher[T]) Remove(host string) bool {
+	return m.remove(host, "")
+}
+
+func (m *HostMatcher[T]) RemoveRoute(host string, path string) bool {
+	return m.remove(host, path)
+}
+
+func (m *HostMatcher[T]) Match(host string) (T, string, map[string]string) {
+	return m.match(m.hosts, hostKey(host))
+}
+
+func (m *HostMatcher[T]) MatchRoute(host string, path string) (T, string, map[string]string) {
+	return m.match(m.routes, hostKey(host)+hostBoundary+path)
+}
+
+func (m *HostMatcher[T]) add(host string, path string, value T, strict bool) error {
+	matcher, pattern, variants, err := m.parse(host, path)
+	if err != nil {
+		return err
+	}
+
+	if strict {
+		for _, v := range variants {
+			if err := matcher.conflict(pattern, v, nil); err != nil {
+				return err
+			}
+		}
+	}
+	for _, v := range variants {
+		matcher.add(pattern, v, value, nil)
+	}
+	return nil
+}
+
+func (m *HostMatcher[T]) remove(host string, path string) bool {
+	matcher, pattern, variants, err := m.parse(host, path)
+	if err != nil {
+		return false
+	}
+
+	removed := false
+	for _, v := range variants {
+		if matcher.removeVariant(pattern, v, nil) {
+			removed = true
+		}
+	}
+	return removed
+}
+
+func (m *HostMatcher[T]) parse(host string, path string) (*PathMatcher[T], string, []pathVariant, error) {
+	name, port := splitHostPort(host)
+	var hostVariants []pathVariant
+	if isIPv6Literal(name) {
+		hostVariants = []pathVariant{{tokens: []pathToken{{kind: staticKind, text: strings.ToLower(name)}}}}
+	} else {
+		variants, err := parsePattern(name)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		for _, v := range variants {
+			hostVariants = append(hostVariants, reverseHostVariant(v))
+		}
+	}
+
+	// The port comes first, so a pattern with a port wins over one without as a static part wins over a param.
+	portToken := pathToken{kind: paramKind, name: anyPort}
+	if port != "" {
+		portToken = pathToken{kind: staticKind, text: port}
+	}
+	portVariant := pathVariant{tokens: []pathToken{portToken}}.concat(pathVariant{tokens: []pathToken{{kind: staticKind, text: hostBoundary}}})
+	hostVariants = concatVariants([][]pathVariant{{portVariant}, hostVariants})
+	if path == "" {
+		return m.hosts, host, hostVariants, nil
+	}
+
+	pathVariants, err := parsePattern(path)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	boundary := []pathVariant{{tokens: []pathToken{{kind: staticKind, text: hostBoundary}}}}
+	return m.routes, host + path, concatVariants([][]pathVariant{hostVariants, boundary, pathVariants}), nil
+}
+
+func (m *HostMatcher[T]) match(matcher *PathMatcher[T], key string) (T, string, map[string]string) {
+	r, paramValues, _ := matcher.matchFirst(key, nil)
+	if r == nil {
+		var zero T
+		return zero, "", nil
+	}
+
+	// Values captured in the host are made of reversed labels.
+	values := make([]string, len(paramValues))
+	copy(values, paramValues)
+	i, boundaries := 0, 0
+	for _, t := range r.tokens {
+		if t.kind == staticKind {
+			boundaries += strings.Count(t.text, hostBoundary)
+			if boundaries > 1 {
+				break
+			}
+			continue
+		}
+		if boundaries == 1 {
+			values[i] = strings.Join(reverseLabels(strings.Split(values[i], "/")), ".")
+		}
+		i++
+	}
+
+	params := r.params(values, false)
+	delete(params, anyPort)
+	return r.value, r.pristinePath, params
+}
+
+func reverseHostVariant(v pathVariant) pathVariant {
+	labels := [][]pathToken{nil}
+	for _, t := range v.tokens {
+		if t.kind != staticKind {
+			labels[len(labels)-1] = append(labels[len(labels)-1], t)
+			continue
+		}
+		for i, part := range strings.Split(strings.ToLower(t.text), ".") {
+			if i > 0 {
+				labels = append(labels, nil)
+			}
+			if part != "" {
+				labels[len(labels)-1] = append(labels[len(labels)-1], pathToken{kind: staticKind, text: part})
+			}
+		}
+	}
+
+	res := pathVariant{defaults: v.defaults}
+	for i := len(labels) - 1; i >= 0; i-- {
+		label := pathVariant{tokens: labels[i]}
+		if i < len(labels)-1 {
+			label = pathVariant{tokens: []pathToken{{kind: staticKind, text: "/"}}}.concat(label)
+		}
+		res = res.concat(label)
+	}
+	return res
+}
+
+func hostKey(host string) string {
+	host, port := splitHostPort(host)
+	return port + hostBoundary + reverseHost(host)
+}
+
+func reverseHost(host string) string {
+	if isIPv6Literal(host) {
+		return strings.ToLower(host)
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	return strings.Join(reverseLabels(strings.Split(host, ".")), "/")
+}
+
+// splitHostPort splits off a trailing port made of digits, anything else after the last colon is a param of the host.
+// An IPv6 address in brackets is kept whole, the port can only follow the closing bracket.
+func splitHostPort(host string) (string, string) {
+	i := strings.LastIndexByte(host, ':')
+	if end := strings.IndexByte(host, ']'); strings.HasPrefix(host, "[") && (end < 0 || i != end+1) {
+		return host, ""
+	}
+	if i < 0 || i == len(host)-1 {
+		return host, ""
+	}
+	for j := i + 1; j < len(host); j++ {
+		if host[j] < '0' || host[j] > '9' {
+			return host, ""
+		}
+	}
+	return host[:i], host[i+1:]
+}
+
+// isIPv6Literal reports whether host is an IPv6 address in brackets, matched as one literal label.
+func isIPv6Literal(host string) bool {
+	return strings.HasPrefix(host, "[") && strings.HasSuffix(host, "]")
+}
+
+func reverseLabels(labels []string) []string {
+	for i, j := 0, len(labels)-1; i < j; i, j = i+1, j-1 {
+		labels[i], labels[j] = labels[j], labels[i]
+	}
+	return labels
+}
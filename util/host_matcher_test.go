@@ -0,0 +1,279 @@
+package util
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestHostMatcher_Match(t *testing.T) {
+	m := NewHostMatcher[string]()
+
+	m.Add("example.com", "root")
+	m.Add("api.example.com", "api")
+	m.Add(":tenant.api.example.com", "tenant")
+	m.Add("*.cdn.example.com", "cdn")
+	m.Add("static:n<[0-9]+>.example.com", "static")
+
+	testCases := []struct {
+		whenHost      string
+		expectValue   string
+		expectPattern string
+		expectParams  map[string]string
+	}{
+		{
+			whenHost:      "example.com",
+			expectValue:   "root",
+			expectPattern: "example.com",
+			expectParams:  map[string]string{},
+		},
+		{
+			whenHost:      "API.Example.com:8080",
+			expectValue:   "api",
+			expectPattern: "api.example.com",
+			expectParams:  map[string]string{},
+		},
+		{
+			whenHost:      "acme.api.example.com.",
+			expectValue:   "tenant",
+			expectPattern: ":tenant.api.example.com",
+			expectParams:  map[string]string{"tenant": "acme"},
+		},
+		{
+			whenHost:      "a.b.cdn.example.com",
+			expectValue:   "cdn",
+			expectPattern: "*.cdn.example.com",
+			expectParams:  map[string]string{"*": "a.b"},
+		},
+		{
+			whenHost:      "static12.example.com",
+			expectValue:   "static",
+			expectPattern: "static:n<[0-9]+>.example.com",
+			expectParams:  map[string]string{"n": "12"},
+		},
+		{
+			whenHost:      "a.b.api.example.com",
+			expectValue:   "",
+			expectPattern: "",
+			expectParams:  nil,
+		},
+		{
+			whenHost:      "example.org",
+			expectValue:   "",
+			expectPattern: "",
+			expectParams:  nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.whenHost, func(t *testing.T) {
+			value, pattern, params := m.Match(tc.whenHost)
+			assert.Equal(t, tc.expectValue, value)
+			assert.Equal(t, tc.expectPattern, pattern)
+			assert.Equal(t, tc.expectParams, params)
+		})
+	}
+}
+
+func TestHostMatcher_MatchRoute(t *testing.T) {
+	m := NewHostMatcher[string]()
+
+	m.AddRoute(":tenant.api.example.com", "/users/:id", "tenant")
+	m.AddRoute("api.example.com", "/users/:id", "api")
+	m.AddRoute("*.example.com", "/*", "fallback")
+
+	testCases := []struct {
+		whenHost      string
+		whenPath      string
+		expectValue   string
+		expectPattern string
+		expectParams  map[string]string
+	}{
+		{
+			whenHost:      "acme.api.example.com",
+			whenPath:      "/users/1",
+			expectValue:   "tenant",
+			expectPattern: ":tenant.api.example.com/users/:id",
+			expectParams:  map[string]string{"tenant": "acme", "id": "1"},
+		},
+		{
+			whenHost:      "api.example.com",
+			whenPath:      "/users/1",
+			expectValue:   "api",
+			expectPattern: "api.example.com/users/:id",
+			expectParams:  map[string]string{"id": "1"},
+		},
+		{
+			whenHost:      "a.b.example.com",
+			whenPath:      "/c/d",
+			expectValue:   "fallback",
+			expectPattern: "*.example.com/*",
+			expectParams:  map[string]string{"*": "c/d"},
+		},
+		{
+			whenHost:      "example.com",
+			whenPath:      "/users/1",
+			expectValue:   "",
+			expectPattern: "",
+			expectParams:  nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.whenHost+tc.whenPath, func(t *testing.T) {
+			value, pattern, params := m.MatchRoute(tc.whenHost, tc.whenPath)
+			assert.Equal(t, tc.expectValue, value)
+			assert.Equal(t, tc.expectPattern, pattern)
+			assert.Equal(t, tc.expectParams, params)
+		})
+	}
+
+	assert.True(t, m.RemoveRoute(":tenant.api.example.com", "/users/:id"))
+	assert.False(t, m.RemoveRoute(":tenant.api.example.com", "/users/:id"))
+}
+
+func TestHostMatcher_AddE(t *testing.T) {
+	m := NewHostMatcher[string]()
+
+	assert.NoError(t, m.AddE(":tenant.example.com", "tenant"))
+	assert.ErrorIs(t, m.AddE(":tenant.example.com", "tenant"), ErrRouteConflict)
+	assert.ErrorIs(t, m.AddE(":name.example.com", "name"), ErrRouteConflict)
+	assert.ErrorIs(t, m.AddE(":tenant.example.com(", "broken"), ErrInvalidPattern)
+
+	assert.True(t, m.Remove(":tenant.example.com"))
+	assert.False(t, m.Remove(":tenant.example.com"))
+
+	assert.NoError(t, m.AddE("api.example.com:8080", "8080"))
+	assert.NoError(t, m.AddE("api.example.com", "any"))
+	assert.ErrorIs(t, m.AddE("api.example.com:8080", "8080"), ErrRouteConflict)
+}
+
+func TestHostMatcher_MatchPort(t *testing.T) {
+	m := NewHostMatcher[string]()
+
+	m.Add("api.example.com:8080", "8080")
+	m.Add(":tenant.example.com:8443", "tenant")
+	m.Add("www.example.com", "any")
+	m.Add("www.example.com:8080", "www")
+	m.AddRoute("api.example.com:8080", "/users/:id", "users")
+
+	testCases := []struct {
+		whenHost      string
+		expectValue   string
+		expectPattern string
+		expectParams  map[string]string
+	}{
+		{
+			whenHost:      "api.example.com:8080",
+			expectValue:   "8080",
+			expectPattern: "api.example.com:8080",
+			expectParams:  map[string]string{},
+		},
+		{
+			whenHost: "api.example.com:9090",
+		},
+		{
+			whenHost: "api.example.com",
+		},
+		{
+			whenHost:      "acme.example.com:8443",
+			expectValue:   "tenant",
+			expectPattern: ":tenant.example.com:8443",
+			expectParams:  map[string]string{"tenant": "acme"},
+		},
+		{
+			whenHost: "acme.example.com",
+		},
+		{
+			whenHost:      "www.example.com:8080",
+			expectValue:   "www",
+			expectPattern: "www.example.com:8080",
+			expectParams:  map[string]string{},
+		},
+		{
+			whenHost:      "www.example.com:9090",
+			expectValue:   "any",
+			expectPattern: "www.example.com",
+			expectParams:  map[string]string{},
+		},
+		{
+			whenHost:      "www.example.com",
+			expectValue:   "any",
+			expectPattern: "www.example.com",
+			expectParams:  map[string]string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.whenHost, func(t *testing.T) {
+			value, pattern, params := m.Match(tc.whenHost)
+			assert.Equal(t, tc.expectValue, value)
+			assert.Equal(t, tc.expectPattern, pattern)
+			assert.Equal(t, tc.expectParams, params)
+		})
+	}
+
+	value, _, params := m.MatchRoute("api.example.com:8080", "/users/1")
+	assert.Equal(t, "users", value)
+	assert.Equal(t, map[string]string{"id": "1"}, params)
+
+	value, _, _ = m.MatchRoute("api.example.com:9090", "/users/1")
+	assert.Equal(t, "", value)
+}
+
+func TestHostMatcher_MatchIPv6(t *testing.T) {
+	m := NewHostMatcher[string]()
+
+	assert.NoError(t, m.AddE("[::1]", "any"))
+	assert.NoError(t, m.AddE("[::1]:8080", "8080"))
+	assert.NoError(t, m.AddE("[::FFFF:127.0.0.1]", "mapped"))
+	assert.NoError(t, m.AddRouteE("[::1]:8080", "/users/:id", "users"))
+	assert.ErrorIs(t, m.AddE("[::1]", "again"), ErrRouteConflict)
+
+	testCases := []struct {
+		whenHost      string
+		expectValue   string
+		expectPattern string
+		expectParams  map[string]string
+	}{
+		{
+			whenHost:      "[::1]",
+			expectValue:   "any",
+			expectPattern: "[::1]",
+			expectParams:  map[string]string{},
+		},
+		{
+			whenHost:      "[::1]:9090",
+			expectValue:   "any",
+			expectPattern: "[::1]",
+			expectParams:  map[string]string{},
+		},
+		{
+			whenHost:      "[::1]:8080",
+			expectValue:   "8080",
+			expectPattern: "[::1]:8080",
+			expectParams:  map[string]string{},
+		},
+		{
+			whenHost:      "[::ffff:127.0.0.1]",
+			expectValue:   "mapped",
+			expectPattern: "[::FFFF:127.0.0.1]",
+			expectParams:  map[string]string{},
+		},
+		{
+			whenHost: "[::2]",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.whenHost, func(t *testing.T) {
+			value, pattern, params := m.Match(tc.whenHost)
+			assert.Equal(t, tc.expectValue, value)
+			assert.Equal(t, tc.expectPattern, pattern)
+			assert.Equal(t, tc.expectParams, params)
+		})
+	}
+
+	value, _, params := m.MatchRoute("[::1]:8080", "/users/1")
+	assert.Equal(t, "users", value)
+	assert.Equal(t, map[string]string{"id": "1"}, params)
+}
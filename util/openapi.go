@@ -0,0 +1,110 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+type (
+	OpenAPIRoute struct {
+		Path       string
+		Pattern    string
+		Operations map[string]string
+	}
+
+	openAPIDocument struct {
+		OpenAPI string                          `yaml:"openapi"`
+		Paths   map[string]map[string]yaml.Node `yaml:"paths"`
+	}
+
+	openAPIOperation struct {
+		OperationID string `yaml:"operationId"`
+	}
+)
+
+var (
+	ErrInvalidDocument = errors.New("invalid document")
+)
+
+var openAPIMethods = []string{
+	http.MethodGet, http.MethodPut, http.MethodPost, http.MethodDelete,
+	http.MethodOptions, http.MethodHead, http.MethodPatch, http.MethodTrace,
+}
+
+// LoadOpenAPI adds the paths of an OpenAPI 3 document, in JSON or YAML, with the value made from their operations.
+func LoadOpenAPI[T any](m *PathMatcher[T], document []byte, value func(route OpenAPIRoute) T) error {
+	var doc openAPIDocument
+	if err := yaml.Unmarshal(document, &doc); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidDocument, err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return fmt.Errorf("%w: OpenAPI version %q", ErrUnsupportedSyntax, doc.OpenAPI)
+	}
+
+	paths := make([]string, 0, len(doc.Paths))
+	for path := range doc.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	// Convert every path first, so an unsupported one leaves the matcher as it was.
+	routes := make([]OpenAPIRoute, 0, len(paths))
+	for _, path := range paths {
+		item := doc.Paths[path]
+		if _, ok := item["$ref"]; ok {
+			return fmt.Errorf("%w: %q refers to another path item", ErrUnsupportedSyntax, path)
+		}
+
+		pattern, err := ImportPattern(SyntaxOpenAPI, path)
+		if err != nil {
+			return err
+		}
+
+		route := OpenAPIRoute{Path: path, Pattern: pattern, Operations: map[string]string{}}
+		for _, method := range openAPIMethods {
+			n, ok := item[strings.ToLower(method)]
+			if !ok {
+				continue
+			}
+			var operation openAPIOperation
+			if err := n.Decode(&operation); err != nil {
+				return fmt.Errorf("%w: %s %q: %s", ErrInvalidDocument, method, path, err)
+			}
+			route.Operations[method] = operation.OperationID
+		}
+		routes = append(routes, route)
+	}
+
+	// Check every route, against the matcher and the routes before it, before adding any.
+	staged := &PathMatcher[struct{}]{tree: &node[struct{}]{}, options: m.options}
+	variants := make([][]pathVariant, len(routes))
+	for i, route := range routes {
+		var err error
+		if variants[i], err = parsePattern(route.Pattern); err != nil {
+			return err
+		}
+		for _, v := range variants[i] {
+			if err := m.conflict(route.Pattern, v, nil); err != nil {
+				return err
+			}
+			if err := staged.conflict(route.Pattern, v, nil); err != nil {
+				return err
+			}
+		}
+		for _, v := range variants[i] {
+			staged.add(route.Pattern, v, struct{}{}, nil)
+		}
+	}
+
+	for i, route := range routes {
+		v := value(route)
+		for _, variant := range variants[i] {
+			m.add(route.Pattern, variant, v, nil)
+		}
+	}
+	return nil
+}
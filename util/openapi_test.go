@@ -0,0 +1,95 @@
+package util
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestLoadOpenAPI(t *testing.T) {
+	document := []byte(`
+openapi: 3.0.3
+info:
+  title: users
+  version: 1.0.0
+paths:
+  /users:
+    get:
+      operationId: listUsers
+    post:
+      operationId: createUser
+  /users/{id}:
+    parameters:
+      - name: id
+        in: path
+        required: true
+    get:
+      operationId: getUser
+`)
+
+	m := NewPathMatcher[OpenAPIRoute]()
+	assert.NoError(t, LoadOpenAPI(m, document, func(route OpenAPIRoute) OpenAPIRoute {
+		return route
+	}))
+
+	value, pattern, params := m.Match("/users/1")
+	assert.Equal(t, "/users/:id", pattern)
+	assert.Equal(t, map[string]string{"id": "1"}, params)
+	assert.Equal(t, OpenAPIRoute{
+		Path:       "/users/{id}",
+		Pattern:    "/users/:id",
+		Operations: map[string]string{"GET": "getUser"},
+	}, value)
+
+	value, _, _ = m.Match("/users")
+	assert.Equal(t, map[string]string{"GET": "listUsers", "POST": "createUser"}, value.Operations)
+}
+
+func TestLoadOpenAPI_Error(t *testing.T) {
+	testCases := []struct {
+		whenDocument string
+		expectErr    error
+	}{
+		{
+			whenDocument: `{"openapi": "3.1.0", "paths": {"/users/{user-id}": {}}}`,
+			expectErr:    ErrUnsupportedSyntax,
+		},
+		{
+			whenDocument: `{"openapi": "3.1.0", "paths": {"/users": {"$ref": "#/components/pathItems/users"}}}`,
+			expectErr:    ErrUnsupportedSyntax,
+		},
+		{
+			whenDocument: `{"swagger": "2.0", "paths": {}}`,
+			expectErr:    ErrUnsupportedSyntax,
+		},
+		{
+			whenDocument: `{"openapi": "3.1.0", "paths": {"/users/{id}": {}, "/users/{name}": {}}}`,
+			expectErr:    ErrRouteConflict,
+		},
+		{
+			whenDocument: `openapi: [`,
+			expectErr:    ErrInvalidDocument,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.whenDocument, func(t *testing.T) {
+			m := NewPathMatcher[string]()
+			err := LoadOpenAPI(m, []byte(tc.whenDocument), func(route OpenAPIRoute) string {
+				return route.Path
+			})
+			assert.ErrorIs(t, err, tc.expectErr)
+			assert.Empty(t, m.Routes())
+		})
+	}
+}
+
+func TestLoadOpenAPI_Conflict(t *testing.T) {
+	m := NewPathMatcher[string]()
+	m.Add("/users/:name", "name")
+
+	err := LoadOpenAPI(m, []byte(`{"openapi": "3.1.0", "paths": {"/a": {}, "/b": {}, "/users/{id}": {}}}`), func(route OpenAPIRoute) string {
+		return route.Path
+	})
+	assert.ErrorIs(t, err, ErrRouteConflict)
+	assert.Equal(t, []string{"/users/:name"}, m.Routes())
+}
@@ -0,0 +1,156 @@
+package util
+
+import (
+	"strings"
+)
+
+type (
+	PathGroup[T any] struct {
+		matcher *PathMatcher[T]
+		prefix  string
+		// routes, mounts and groups record what was added through the group, Clear removes exactly that.
+		routes map[string]routeRef
+		mounts []string
+		groups []*PathGroup[T]
+	}
+)
+
+func (m *PathMatcher[T]) Group(prefix string) *PathGroup[T] {
+	return &PathGroup[T]{
+		matcher: m,
+		prefix:  prefix,
+		routes:  map[string]routeRef{},
+	}
+}
+
+func (m *PathMatcher[T]) Mount(prefix string, other *PathMatcher[T]) error {
+	type mount struct {
+		pattern    string
+		variants   []pathVariant
+		predicates []routePredicate
+		value      T
+	}
+
+	// Check every route before adding any, so a conflict leaves the matcher as it was.
+	var mounts []mount
+	var err error
+	visited := map[string]bool{}
+	other.tree.walk(0, func(n *node[T], _ int) bool {
+		for _, r := range n.routes() {
+			// Optional parts put one pattern on several nodes, mount it once.
+			key := routeKey(r.pristinePath, r.predicates)
+			if visited[key] {
+				continue
+			}
+			visited[key] = true
+
+			pattern := joinPattern(prefix, r.pristinePath)
+			var variants []pathVariant
+			if variants, err = parsePattern(pattern); err != nil {
+				return false
+			}
+			for _, v := range variants {
+				if err = m.conflict(pattern, v, r.predicates); err != nil {
+					return false
+				}
+			}
+			mounts = append(mounts, mount{pattern: pattern, variants: variants, predicates: r.predicates, value: r.value})
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	if m.mounts == nil {
+		m.mounts = map[string][]routeRef{}
+	}
+	for _, o := range mounts {
+		for _, v := range o.variants {
+			m.add(o.pattern, v, o.value, o.predicates)
+		}
+		m.mounts[prefix] = append(m.mounts[prefix], routeRef{pattern: o.pattern, predicates: o.predicates})
+	}
+	return nil
+}
+
+func (m *PathMatcher[T]) Unmount(prefix string) int {
+	refs := m.mounts[prefix]
+	delete(m.mounts, prefix)
+
+	count := 0
+	for _, r := range refs {
+		if m.remove(r.pattern, r.predicates) {
+			count++
+		}
+	}
+	return count
+}
+
+func (g *PathGroup[T]) Add(path string, value T, options ...RouteOption) {
+	pattern := joinPattern(g.prefix, path)
+	g.matcher.Add(pattern, value, options...)
+	g.record(pattern, options)
+}
+
+func (g *PathGroup[T]) AddE(path string, value T, options ...RouteOption) error {
+	pattern := joinPattern(g.prefix, path)
+	if err := g.matcher.AddE(pattern, value, options...); err != nil {
+		return err
+	}
+	g.record(pattern, options)
+	return nil
+}
+
+func (g *PathGroup[T]) Remove(path string, options ...RouteOption) bool {
+	pattern := joinPattern(g.prefix, path)
+	delete(g.routes, routeKey(pattern, newRouteOptions(options).predicates))
+	return g.matcher.Remove(pattern, options...)
+}
+
+func (g *PathGroup[T]) Group(prefix string) *PathGroup[T] {
+	group := g.matcher.Group(joinPattern(g.prefix, prefix))
+	g.groups = append(g.groups, group)
+	return group
+}
+
+func (g *PathGroup[T]) Mount(prefix string, other *PathMatcher[T]) error {
+	prefix = joinPattern(g.prefix, prefix)
+	if err := g.matcher.Mount(prefix, other); err != nil {
+		return err
+	}
+	g.mounts = append(g.mounts, prefix)
+	return nil
+}
+
+func (g *PathGroup[T]) Clear() int {
+	count := 0
+	for _, r := range g.routes {
+		if g.matcher.remove(r.pattern, r.predicates) {
+			count++
+		}
+	}
+	for _, prefix := range g.mounts {
+		count += g.matcher.Unmount(prefix)
+	}
+	for _, group := range g.groups {
+		count += group.Clear()
+	}
+
+	g.routes = map[string]routeRef{}
+	g.mounts = nil
+	g.groups = nil
+	return count
+}
+
+func (g *PathGroup[T]) record(pattern string, options []RouteOption) {
+	predicates := newRouteOptions(options).predicates
+	g.routes[routeKey(pattern, predicates)] = routeRef{pattern: pattern, predicates: predicates}
+}
+
+func joinPattern(prefix string, path string) string {
+	if strings.HasSuffix(prefix, "/") && strings.HasPrefix(path, "/") {
+		return prefix + path[1:]
+	}
+	return prefix + path
+}
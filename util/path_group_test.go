@@ -0,0 +1,116 @@
+package util
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPathGroup_Add(t *testing.T) {
+	m := NewPathMatcher[string]()
+
+	api := m.Group("/api/v1")
+	api.Add("/users/:id", "user")
+	api.Group("/admin/").Add("/stats", "stats")
+
+	assert.Equal(t, []string{"/api/v1/users/:id", "/api/v1/admin/stats"}, m.Routes())
+
+	value, pattern, params := m.Match("/api/v1/users/1")
+	assert.Equal(t, "user", value)
+	assert.Equal(t, "/api/v1/users/:id", pattern)
+	assert.Equal(t, map[string]string{"id": "1"}, params)
+
+	assert.ErrorIs(t, api.AddE("/users/:name", "name"), ErrRouteConflict)
+	assert.True(t, api.Remove("/users/:id"))
+	assert.False(t, api.Remove("/users/:id"))
+}
+
+func TestPathMatcher_Mount(t *testing.T) {
+	users := NewPathMatcher[string]()
+	users.Add("/", "list")
+	users.Add("/:id(/:tab)", "user")
+
+	m := NewPathMatcher[string]()
+	m.Add("/tenants/:tenant/usage", "usage")
+	assert.NoError(t, m.Mount("/tenants/:tenant/users", users))
+
+	testCases := []struct {
+		whenPath      string
+		expectValue   string
+		expectPattern string
+		expectParams  map[string]string
+	}{
+		{
+			whenPath:      "/tenants/acme/users/",
+			expectValue:   "list",
+			expectPattern: "/tenants/:tenant/users/",
+			expectParams:  map[string]string{"tenant": "acme"},
+		},
+		{
+			whenPath:      "/tenants/acme/users/1/posts",
+			expectValue:   "user",
+			expectPattern: "/tenants/:tenant/users/:id(/:tab)",
+			expectParams:  map[string]string{"tenant": "acme", "id": "1", "tab": "posts"},
+		},
+		{
+			whenPath:      "/tenants/acme/usage",
+			expectValue:   "usage",
+			expectPattern: "/tenants/:tenant/usage",
+			expectParams:  map[string]string{"tenant": "acme"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.whenPath, func(t *testing.T) {
+			value, pattern, params := m.Match(tc.whenPath)
+			assert.Equal(t, tc.expectValue, value)
+			assert.Equal(t, tc.expectPattern, pattern)
+			assert.Equal(t, tc.expectParams, params)
+		})
+	}
+
+	assert.ErrorIs(t, m.Mount("/tenants/:tenant/users", users), ErrRouteConflict)
+	assert.Len(t, m.Routes(), 3)
+
+	m.Add("/tenants/:tenant/users/export", "export")
+
+	assert.Equal(t, 2, m.Unmount("/tenants/:tenant/users"))
+	assert.Equal(t, []string{"/tenants/:tenant/usage", "/tenants/:tenant/users/export"}, m.Routes())
+	assert.Equal(t, 0, m.Unmount("/tenants/:tenant/users"))
+}
+
+func TestPathGroup_Clear(t *testing.T) {
+	m := NewPathMatcher[string]()
+	m.Add("/api", "root")
+	m.Add("/apis", "apis")
+
+	api := m.Group("/api")
+	api.Add("/a", "a")
+	api.Add("/b/:id", "b")
+
+	assert.Equal(t, 2, api.Clear())
+	assert.Equal(t, []string{"/api", "/apis"}, m.Routes())
+}
+
+func TestPathGroup_ClearShared(t *testing.T) {
+	m := NewPathMatcher[string]()
+
+	orders := NewPathMatcher[string]()
+	orders.Add("/orders/:id", "order")
+
+	users := m.Group("/api")
+	users.Add("/users/:id", "user")
+	users.Group("/admin").Add("/users", "admin")
+
+	billing := m.Group("/api")
+	billing.Add("/invoices/:id", "invoice", WithQuery("format", "pdf"))
+	assert.NoError(t, billing.Mount("/shop", orders))
+
+	m.Add("/api/health", "health")
+
+	assert.Equal(t, 2, users.Clear())
+	assert.ElementsMatch(t, []string{"/api/invoices/:id", "/api/shop/orders/:id", "/api/health"}, m.Routes())
+
+	assert.Equal(t, 2, billing.Clear())
+	assert.Equal(t, []string{"/api/health"}, m.Routes())
+	assert.Equal(t, 0, billing.Clear())
+}
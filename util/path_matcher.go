@@ -1,22 +1,77 @@
 package util
 
+import (
+	"fmt"
+	"golang.org/x/exp/slices"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
 type (
-	PathMatcher struct {
-		tree *node
+	PathMatcher[T any] struct {
+		tree    *node[T]
+		options pathMatcherOptions
+		// mounts keeps the routes added by each Mount, so Unmount removes those and nothing else.
+		mounts map[string][]routeRef
 	}
 
-	node struct {
+	routeRef struct {
+		pattern    string
+		predicates []routePredicate
+	}
+
+	node[T any] struct {
+		route[T]
 		kind           kind
 		prefix         string
-		parent         *node
-		staticChildren children
-		paramChild     *node
-		anyChild       *node
-		pristinePath   string
-		paramNames     []string
-	}
-	kind     uint8
-	children []*node
+		parent         *node[T]
+		staticChildren children[T]
+		paramChildren  children[T]
+		anyChild       *node[T]
+		constraint     *regexp.Regexp
+		// guarded are the routes with predicates, they are tried before the route of the node.
+		guarded []*route[T]
+	}
+
+	route[T any] struct {
+		pristinePath string
+		paramNames   []string
+		defaults     map[string]string
+		tokens       []pathToken
+		predicates   []routePredicate
+		value        T
+	}
+	kind            uint8
+	children[T any] []*node[T]
+
+	matchContext[T any] struct {
+		caseInsensitive bool
+		escaped         bool
+		unescapeParams  bool
+		trailingSlash   bool
+		segmentParams   bool
+		// prefix lets routes end on a segment boundary before the end of the path, leaving rest unmatched.
+		prefix  bool
+		rest    string
+		request *routeRequest
+		visit   func(r *route[T], paramValues []string) bool
+	}
+
+	PathMatch[T any] struct {
+		Value   T
+		Pattern string
+		Params  map[string]string
+	}
+
+	RouteConflictError struct {
+		Pattern  string
+		Existing string
+		Reason   string
+	}
 )
 
 const (
@@ -29,417 +84,830 @@ const (
 )
 
 func MatchPath(pattern string, path string) (bool, map[string]string) {
-	m := NewPathMatcher()
-	m.Add(pattern)
-	res, params := m.Match(path)
-	if res != pattern {
+	p, err := patterns.compile(pattern)
+	if err != nil {
 		return false, nil
 	}
-	return true, params
+	return p.Match(path)
 }
 
-func NewPathMatcher() *PathMatcher {
-	return &PathMatcher{
-		tree: &node{},
+func NewPathMatcher[T any](options ...PathMatcherOption) *PathMatcher[T] {
+	m := &PathMatcher[T]{
+		tree: &node[T]{},
+	}
+	for _, option := range options {
+		option(&m.options)
 	}
+	return m
 }
 
-func (m *PathMatcher) Add(path string) {
-	var paramNames []string
-	pristinePath := path
+func (m *PathMatcher[T]) Add(path string, value T, options ...RouteOption) {
+	variants, err := parsePattern(path)
+	if err != nil {
+		panic(err)
+	}
+	o := newRouteOptions(options)
+	for _, v := range variants {
+		m.add(path, v, value, o.predicates)
+	}
+}
 
-	for i, lcpIndex := 0, len(path); i < lcpIndex; i++ {
-		if path[i] == ':' {
-			if i > 0 && path[i-1] == '\\' {
-				path = path[:i-1] + path[i:]
-				i--
-				lcpIndex--
-				continue
-			}
-			j := i + 1
+func (m *PathMatcher[T]) AddE(path string, value T, options ...RouteOption) error {
+	variants, err := parsePattern(path)
+	if err != nil {
+		return err
+	}
+	o := newRouteOptions(options)
+	for _, v := range variants {
+		if err := m.conflict(path, v, o.predicates); err != nil {
+			return err
+		}
+	}
+	for _, v := range variants {
+		m.add(path, v, value, o.predicates)
+	}
+	return nil
+}
 
-			m.insert(path[:i], staticKind, "", nil)
-			for ; i < lcpIndex && path[i] != '/'; i++ {
-			}
+func (m *PathMatcher[T]) Remove(path string, options ...RouteOption) bool {
+	return m.remove(path, newRouteOptions(options).predicates)
+}
 
-			paramNames = append(paramNames, path[j:i])
-			path = path[:j] + path[i:]
-			i, lcpIndex = j, len(path)
+func (m *PathMatcher[T]) Match(path string) (T, string, map[string]string) {
+	return m.match(path, nil)
+}
 
-			if i == lcpIndex {
-				m.insert(path[:i], paramKind, pristinePath, paramNames)
-			} else {
-				m.insert(path[:i], paramKind, "", nil)
+func (m *PathMatcher[T]) MatchRequest(r *http.Request) (T, string, map[string]string) {
+	return m.match(m.requestPath(r), r)
+}
+
+// MatchPrefix matches the longest route accepting a prefix of path that ends on a segment boundary, and returns the rest.
+// The rest is always rooted at /, whether or not the pattern ends with a slash, and is / when nothing is left.
+func (m *PathMatcher[T]) MatchPrefix(path string) (T, string, map[string]string, string) {
+	var res *route[T]
+	var paramValues []string
+	var rest string
+
+	ctx := &matchContext[T]{
+		caseInsensitive: m.options.caseInsensitive,
+		escaped:         m.options.escapedPath,
+		unescapeParams:  m.options.unescapeParams(),
+		segmentParams:   true,
+		prefix:          true,
+	}
+	ctx.visit = func(r *route[T], values []string) bool {
+		if res == nil || len(ctx.rest) < len(rest) {
+			res, paramValues, rest = r, append([]string(nil), values...), ctx.rest
+		}
+		return false
+	}
+	m.tree.match(m.options.normalize(path), nil, ctx)
+
+	if res == nil {
+		var zero T
+		return zero, "", nil, ""
+	}
+	if !strings.HasPrefix(rest, "/") {
+		rest = "/" + rest
+	}
+	return res.value, res.pristinePath, res.params(paramValues, m.options.unescapeParams()), rest
+}
+
+// MatchInto is Match writing the params into dst, so reusing dst avoids allocating on every call.
+func (m *PathMatcher[T]) MatchInto(path string, dst *Params) (T, string) {
+	var res *route[T]
+	m.lookup(path, nil, dst.values[:0], func(r *route[T], values []string) bool {
+		res, dst.values = r, values
+		return true
+	})
+	if res == nil {
+		dst.Reset()
+		var zero T
+		return zero, ""
+	}
+
+	dst.names = append(dst.names[:0], res.paramNames...)
+	if m.options.unescapeParams() {
+		for i, v := range dst.values {
+			if unescaped, err := url.PathUnescape(v); err == nil {
+				dst.values[i] = unescaped
 			}
-		} else if path[i] == '*' {
-			m.insert(path[:i], staticKind, "", nil)
-			paramNames = append(paramNames, "*")
-			m.insert(path[:i+1], anyKind, pristinePath, paramNames)
 		}
 	}
+	for name, v := range res.defaults {
+		if !slices.Contains(res.paramNames, name) {
+			dst.names = append(dst.names, name)
+			dst.values = append(dst.values, v)
+		}
+	}
+	return res.value, res.pristinePath
+}
 
-	m.insert(path, staticKind, pristinePath, paramNames)
+func (m *PathMatcher[T]) MatchAll(path string) []PathMatch[T] {
+	var matches []PathMatch[T]
+	visited := map[*route[T]]bool{}
+	m.lookup(path, nil, nil, func(r *route[T], values []string) bool {
+		if visited[r] {
+			return false
+		}
+		visited[r] = true
+
+		matches = append(matches, PathMatch[T]{
+			Value:   r.value,
+			Pattern: r.pristinePath,
+			Params:  r.params(values, m.options.unescapeParams()),
+		})
+		return false
+	})
+	return matches
 }
 
-func (m *PathMatcher) Remove(path string) bool {
-	currentNode := m.tree
-	var nodeToRemove *node
-	prefixLen := 0
-	for {
-		if currentNode.pristinePath == path {
-			nodeToRemove = currentNode
-			break
-		}
-		if currentNode.kind == staticKind {
-			prefixLen = prefixLen + len(currentNode.prefix)
-		} else {
-			for ; prefixLen < len(path) && path[prefixLen] != '/'; prefixLen++ {
+func (m *PathMatcher[T]) Canonical(path string) (string, bool) {
+	res, _, canonical := m.matchFirst(path, nil)
+	return canonical, res != nil
+}
+
+func (m *PathMatcher[T]) Build(path string, params map[string]string) (string, error) {
+	variants, err := parsePattern(path)
+	if err != nil {
+		return "", err
+	}
+
+	if n := m.find(variants[0].tokens); n == nil || !n.hasPattern(path) {
+		return "", fmt.Errorf("%w: %q", ErrUnknownPattern, path)
+	}
+	return buildPath(path, variants, params)
+}
+
+func (m *PathMatcher[T]) Routes() []string {
+	var routes []string
+	m.Walk(func(pattern string, _ []string) bool {
+		routes = append(routes, pattern)
+		return true
+	})
+	return routes
+}
+
+func (m *PathMatcher[T]) Walk(fn func(pattern string, paramNames []string) bool) {
+	// Optional parts put one pattern on several nodes, report it once.
+	visited := map[string]bool{}
+	m.tree.walk(0, func(n *node[T], _ int) bool {
+		for _, r := range n.routes() {
+			if visited[r.pristinePath] {
+				continue
+			}
+			visited[r.pristinePath] = true
+
+			paramNames := r.paramNames
+			if variants, err := parsePattern(r.pristinePath); err == nil {
+				paramNames = variants[0].paramNames()
+			}
+			if !fn(r.pristinePath, paramNames) {
+				return false
 			}
 		}
+		return true
+	})
+}
 
-		if prefixLen >= len(path) {
-			break
+func (m *PathMatcher[T]) String() string {
+	var sb strings.Builder
+	m.tree.walk(-1, func(n *node[T], depth int) bool {
+		if depth < 0 {
+			return true
 		}
 
-		next := path[prefixLen]
-		switch next {
-		case paramLabel:
-			currentNode = currentNode.paramChild
-		case anyLabel:
-			currentNode = currentNode.anyChild
-		default:
-			currentNode = currentNode.findStaticChild(next)
+		sb.WriteString(strings.Repeat("  ", depth))
+		sb.WriteString(n.kind.String())
+		sb.WriteString(" ")
+		sb.WriteString(strconv.Quote(n.prefix))
+		if n.constraint != nil {
+			sb.WriteString(" <")
+			sb.WriteString(strings.TrimSuffix(strings.TrimPrefix(n.constraint.String(), "^(?:"), ")$"))
+			sb.WriteString(">")
+		}
+		for i, r := range n.routes() {
+			if i == 0 {
+				sb.WriteString(" -> ")
+			} else {
+				sb.WriteString(", ")
+			}
+			sb.WriteString(routeKey(r.pristinePath, r.predicates))
 		}
+		sb.WriteString("\n")
+		return true
+	})
+	return sb.String()
+}
 
-		if currentNode == nil {
-			break
+func (m *PathMatcher[T]) add(path string, variant pathVariant, value T, predicates []routePredicate) {
+	n := m.insert(variant.tokens)
+	r := route[T]{
+		pristinePath: path,
+		paramNames:   variant.paramNames(),
+		defaults:     variant.defaults,
+		tokens:       variant.tokens,
+		predicates:   predicates,
+		value:        value,
+	}
+	if len(predicates) == 0 {
+		n.route = r
+		return
+	}
+
+	// Routes asking for more are tried first.
+	guarded := make([]*route[T], 0, len(n.guarded)+1)
+	for _, existing := range n.guarded {
+		if !slices.Equal(existing.predicates, predicates) {
+			guarded = append(guarded, existing)
 		}
 	}
+	i := sort.Search(len(guarded), func(i int) bool {
+		return len(guarded[i].predicates) < len(predicates)
+	})
+	n.guarded = slices.Insert(guarded, i, &r)
+}
 
-	if nodeToRemove == nil {
+func (m *PathMatcher[T]) remove(path string, predicates []routePredicate) bool {
+	variants, err := parsePattern(path)
+	if err != nil {
 		return false
 	}
 
-	nodeToRemove.pristinePath = ""
+	removed := false
+	for _, v := range variants {
+		if m.removeVariant(path, v, predicates) {
+			removed = true
+		}
+	}
+	return removed
+}
 
-	if nodeToRemove.isLeaf() {
-		current := nodeToRemove
-		for {
-			parent := current.parent
-			if parent == nil {
-				break
-			}
-			switch current.kind {
-			case staticKind:
-				var index int
-				for i, c := range parent.staticChildren {
-					if c == current {
-						index = i
-						break
-					}
-				}
-				parent.staticChildren = append(parent.staticChildren[:index], parent.staticChildren[index+1:]...)
-			case paramKind:
-				parent.paramChild = nil
-			case anyKind:
-				parent.anyChild = nil
-			}
+func (m *PathMatcher[T]) removeVariant(path string, variant pathVariant, predicates []routePredicate) bool {
+	nodeToRemove := m.find(variant.tokens)
+	if nodeToRemove == nil {
+		return false
+	}
+	r := nodeToRemove.findRoute(predicates)
+	if r == nil || r.pristinePath != path {
+		return false
+	}
 
-			if !parent.isLeaf() {
-				break
+	if len(predicates) == 0 {
+		nodeToRemove.route = route[T]{}
+	} else {
+		guarded := make([]*route[T], 0, len(nodeToRemove.guarded))
+		for _, existing := range nodeToRemove.guarded {
+			if existing != r {
+				guarded = append(guarded, existing)
 			}
-			current = parent
 		}
+		nodeToRemove.guarded = guarded
 	}
 
+	current := nodeToRemove
+	for current.parent != nil && !current.hasRoute() && current.isLeaf() {
+		parent := current.parent
+		parent.removeChild(current)
+		current = parent
+	}
 	return true
 }
 
-func (m *PathMatcher) Match(origin string) (string, map[string]string) {
-	currentNode := m.tree
+func (m *PathMatcher[T]) match(path string, r *http.Request) (T, string, map[string]string) {
+	res, paramValues, _ := m.matchFirst(path, r)
+	if res == nil {
+		var zero T
+		return zero, "", nil
+	}
+	return res.value, res.pristinePath, res.params(paramValues, m.options.unescapeParams())
+}
 
-	var (
-		search      = origin
-		searchIndex = 0
-		paramValues []string
-	)
+func (m *PathMatcher[T]) matchFirst(path string, r *http.Request) (*route[T], []string, string) {
+	var res *route[T]
+	var paramValues []string
+	toggled := m.lookup(path, r, nil, func(r *route[T], values []string) bool {
+		res, paramValues = r, values
+		return true
+	})
+	if res == nil {
+		return nil, nil, ""
+	}
+	return res, paramValues, m.canonical(res, paramValues, toggled)
+}
 
-	backtrackToNextNodeKind := func(fromKind kind) (nextNodeKind kind, valid bool) {
-		previous := currentNode
-		currentNode = previous.parent
-		valid = currentNode != nil
+func (m *PathMatcher[T]) canonical(r *route[T], paramValues []string, toggled bool) string {
+	canonical := r.render(paramValues, m.options.escapedPath)
+	if toggled && m.options.trailingSlash == TrailingSlashIgnore {
+		canonical = toggleTrailingSlash(canonical)
+	}
+	return canonical
+}
 
-		// Next node type by priority
-		if previous.kind == anyKind {
-			nextNodeKind = staticKind
-		} else {
-			nextNodeKind = previous.kind + 1
+// lookup normalizes path and visits the routes accepting it, it reports whether the trailing slash had to be toggled.
+func (m *PathMatcher[T]) lookup(path string, r *http.Request, paramValues []string, visit func(r *route[T], paramValues []string) bool) bool {
+	ctx := &matchContext[T]{
+		caseInsensitive: m.options.caseInsensitive,
+		escaped:         m.options.escapedPath,
+		unescapeParams:  m.options.unescapeParams(),
+		trailingSlash:   m.options.trailingSlash != TrailingSlashStrict,
+		segmentParams:   m.options.segmentParams,
+		visit:           visit,
+	}
+	if r != nil {
+		ctx.request = &routeRequest{request: r}
+	}
+
+	path = m.options.normalize(path)
+	if m.tree.match(path, paramValues, ctx) || m.options.trailingSlash == TrailingSlashStrict || path == "/" {
+		return false
+	}
+
+	return m.tree.match(toggleTrailingSlash(path), paramValues, ctx)
+}
+
+func (m *PathMatcher[T]) requestPath(r *http.Request) string {
+	if m.options.escapedPath {
+		return r.URL.EscapedPath()
+	}
+	return r.URL.Path
+}
+
+func (m *PathMatcher[T]) conflict(path string, variant pathVariant, predicates []routePredicate) error {
+	tokens := variant.tokens
+	if hasAdjacentParams(tokens) {
+		return &RouteConflictError{Pattern: path, Reason: "ambiguous adjacent params"}
+	}
+
+	if n := m.find(tokens); n != nil {
+		if r := n.findRoute(predicates); r != nil {
+			if r.pristinePath == path {
+				return &RouteConflictError{Pattern: path, Existing: r.pristinePath, Reason: "duplicate route"}
+			}
+			return &RouteConflictError{Pattern: path, Existing: r.pristinePath, Reason: "ambiguous route"}
 		}
+	}
+	if existing := m.shadowed(tokens, predicates); existing != "" {
+		return &RouteConflictError{Pattern: path, Existing: existing, Reason: "unreachable route"}
+	}
+	return nil
+}
 
-		if fromKind == staticKind {
-			// when backtracking is done from static basisKind block we did not change search so nothing to restore
-			return
+// shadowed finds a route that keeps the route of tokens from matching, or the other way around. A param ending a
+// route takes the rest of the path, so a wildcard next to it would only ever match an empty rest.
+func (m *PathMatcher[T]) shadowed(tokens []pathToken, predicates []routePredicate) string {
+	if m.options.segmentParams {
+		return ""
+	}
+
+	for i, t := range tokens {
+		if t.kind == staticKind {
+			continue
+		}
+		parent := m.find(tokens[:i])
+		if parent == nil {
+			return ""
 		}
 
-		// restore search to value it was before we move to current node we are backtracking from.
-		if previous.kind == staticKind {
-			searchIndex -= len(previous.prefix)
-		} else if len(paramValues) > 0 {
-			searchIndex -= len(paramValues[len(paramValues)-1])
-			paramValues = paramValues[:len(paramValues)-1]
+		if t.kind == anyKind {
+			for _, child := range parent.paramChildren {
+				if child.swallows() {
+					return child.pristinePath
+				}
+			}
+			continue
+		}
+		if i == len(tokens)-1 && t.constraint == nil && len(predicates) == 0 && parent.anyChild != nil {
+			if child := parent.findParamChild(nil); child != nil && !child.isLeaf() {
+				continue
+			}
+			var existing string
+			parent.anyChild.walk(0, func(n *node[T], _ int) bool {
+				if routes := n.routes(); len(routes) > 0 {
+					existing = routes[0].pristinePath
+				}
+				return existing == ""
+			})
+			return existing
 		}
-		search = origin[searchIndex:]
-		return
 	}
+	return ""
+}
 
-	for {
-		prefixLen := 0
-		lcpLen := 0
+func (m *PathMatcher[T]) clone() *PathMatcher[T] {
+	mounts := make(map[string][]routeRef, len(m.mounts))
+	for prefix, refs := range m.mounts {
+		mounts[prefix] = append([]routeRef(nil), refs...)
+	}
+	return &PathMatcher[T]{
+		tree:    m.tree.clone(nil),
+		options: m.options,
+		mounts:  mounts,
+	}
+}
 
-		if currentNode.kind == staticKind {
-			searchLen := len(search)
-			prefixLen = len(currentNode.prefix)
+func (m *PathMatcher[T]) insert(tokens []pathToken) *node[T] {
+	currentNode := m.tree
 
-			// LCP - Longest Common Prefix (https://en.wikipedia.org/wiki/LCP_array)
-			max := prefixLen
-			if searchLen < max {
-				max = searchLen
+	for _, t := range tokens {
+		switch t.kind {
+		case staticKind:
+			currentNode = currentNode.insertStatic(m.options.static(t.text))
+		case paramKind:
+			child := currentNode.findParamChild(t.constraint)
+			if child == nil {
+				child = newNode(paramKind, string(paramLabel), currentNode)
+				child.constraint = t.constraint
+				currentNode.addParamChild(child)
 			}
-			for ; lcpLen < max && search[lcpLen] == currentNode.prefix[lcpLen]; lcpLen++ {
+			currentNode = child
+		case anyKind:
+			if currentNode.anyChild == nil {
+				currentNode.anyChild = newNode(anyKind, string(anyLabel), currentNode)
 			}
+			currentNode = currentNode.anyChild
 		}
+	}
 
-		if lcpLen != prefixLen {
-			// No matching prefix, let's backtrack to the first possible alternative node of the decision path
-			nk, ok := backtrackToNextNodeKind(staticKind)
-			if !ok {
-				return "", nil
-			} else if nk == paramKind {
-				goto Param
-			} else {
-				// Not found (this should never be possible for static node we are looking currently)
-				break
-			}
+	return currentNode
+}
+
+func (m *PathMatcher[T]) find(tokens []pathToken) *node[T] {
+	currentNode := m.tree
+
+	for _, t := range tokens {
+		switch t.kind {
+		case staticKind:
+			currentNode = currentNode.findStatic(m.options.static(t.text))
+		case paramKind:
+			currentNode = currentNode.findParamChild(t.constraint)
+		case anyKind:
+			currentNode = currentNode.anyChild
+		}
+		if currentNode == nil {
+			return nil
+		}
+	}
+
+	return currentNode
+}
+
+func (k kind) String() string {
+	switch k {
+	case paramKind:
+		return "param"
+	case anyKind:
+		return "any"
+	default:
+		return "static"
+	}
+}
+
+func hasAdjacentParams(tokens []pathToken) bool {
+	for i, t := range tokens {
+		if t.kind != staticKind && i < len(tokens)-1 && tokens[i+1].kind != staticKind {
+			return true
 		}
+	}
+	return false
+}
 
-		// The full prefix has matched, remove the prefix from the remaining search
-		search = search[lcpLen:]
-		searchIndex = searchIndex + lcpLen
+func (e *RouteConflictError) Error() string {
+	if e.Existing == "" {
+		return fmt.Sprintf("%s: %q is %s", ErrRouteConflict, e.Pattern, e.Reason)
+	}
+	return fmt.Sprintf("%s: %q and %q are %s", ErrRouteConflict, e.Pattern, e.Existing, e.Reason)
+}
 
-		// Finish routing if is no request path remaining to search
-		if search == "" && currentNode.pristinePath != "" {
-			break
+func (e *RouteConflictError) Unwrap() error {
+	return ErrRouteConflict
+}
+
+func newNode[T any](kind kind, prefix string, parent *node[T]) *node[T] {
+	return &node[T]{
+		kind:   kind,
+		prefix: prefix,
+		parent: parent,
+	}
+}
+
+// match visits every node that accepts search in priority order (static > param > any) until visit returns true.
+func (n *node[T]) match(search string, paramValues []string, ctx *matchContext[T]) bool {
+	// Finish routing if is no request path remaining to search
+	if search == "" || (ctx.prefix && n.boundary(search)) {
+		ctx.rest = search
+		for _, r := range n.guarded {
+			if r.accepts(ctx.request) && ctx.visit(r, paramValues) {
+				return true
+			}
+		}
+		if n.pristinePath != "" && ctx.visit(&n.route, paramValues) {
+			return true
 		}
+	}
 
+	if search != "" {
 		// Static node
-		if search != "" {
-			if child := currentNode.findStaticChild(search[0]); child != nil {
-				currentNode = child
-				continue
+		if child := n.findStaticChild(ctx.label(search)); child != nil {
+			if i, ok := ctx.hasPrefix(search, child.prefix); ok && child.match(search[i:], paramValues, ctx) {
+				return true
 			}
 		}
 
-	Param:
 		// Param node
-		if child := currentNode.paramChild; search != "" && child != nil {
-			currentNode = child
-			i := 0
-			l := len(search)
-			if currentNode.isLeaf() {
-				// when param node does not have any children (path param is last piece of route path) then param node should
-				// act similarly to any node - consider all remaining search as match
-				i = l
-			} else {
-				for ; i < l && search[i] != '/'; i++ {
+		for _, child := range n.paramChildren {
+			// when param node does not have any children (path param is last piece of route path) then param node should
+			// act similarly to any node - consider all remaining search as match
+			end := len(search)
+			if !child.isLeaf() || ctx.segmentParams {
+				if j := strings.IndexByte(search, '/'); j >= 0 {
+					end = j
 				}
+			} else if ctx.trailingSlash && strings.HasSuffix(search, "/") {
+				// unless the trailing slash is handled by the matcher
+				end--
 			}
 
-			paramValues = append(paramValues, search[:i])
-			search = search[i:]
-			searchIndex = searchIndex + i
-			continue
+			// Try the whole segment first, then stop early at each literal that continues the route inside the segment.
+			for i := end; i >= 0; i-- {
+				if i < end {
+					if len(child.staticChildren) == 0 {
+						break
+					}
+					if i == 0 || child.findStaticChild(ctx.label(search[i:])) == nil {
+						continue
+					}
+				}
+
+				value := search[:i]
+				if child.constraint != nil && !child.constraint.MatchString(ctx.paramValue(value)) {
+					continue
+				}
+				if child.match(search[i:], append(paramValues, value), ctx) {
+					return true
+				}
+			}
 		}
+	}
 
-	Any:
-		// Any node
-		if child := currentNode.anyChild; child != nil {
-			// If any node is found, use remaining path for paramValues
-			currentNode = child
-			paramValues = append(paramValues, search)
+	// Any node
+	if child := n.anyChild; child != nil {
+		// Stop early at each literal that continues the route, longest first, before taking all remaining search.
+		for i := len(search) - 1; i > 0 && len(child.staticChildren) > 0; i-- {
+			if child.findStaticChild(ctx.label(search[i:])) == nil {
+				continue
+			}
+			if child.match(search[i:], append(paramValues, search[:i]), ctx) {
+				return true
+			}
+		}
+
+		if child.match("", append(paramValues, search), ctx) {
+			return true
+		}
+	}
 
-			// update indexes/search in case we need to backtrack when no handler match is found
-			searchIndex += +len(search)
-			search = ""
+	// No other possibilities on the decision path
+	return false
+}
 
-			if currentNode.pristinePath != "" {
-				break
-			}
+// paramValue gives the value a constraint is checked against, the param as it is returned.
+func (ctx *matchContext[T]) paramValue(value string) string {
+	if ctx.unescapeParams {
+		if unescaped, err := url.PathUnescape(value); err == nil {
+			return unescaped
 		}
+	}
+	return value
+}
 
-		// Let's backtrack to the first possible alternative node of the decision path
-		nk, ok := backtrackToNextNodeKind(anyKind)
-		if !ok {
-			break // No other possibilities on the decision path
-		} else if nk == paramKind {
-			goto Param
-		} else if nk == anyKind {
-			goto Any
+func (r *route[T]) accepts(req *routeRequest) bool {
+	for _, p := range r.predicates {
+		if !p.accepts(req) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *route[T]) render(paramValues []string, escape bool) string {
+	var sb strings.Builder
+	i := 0
+	for _, t := range r.tokens {
+		if t.kind == staticKind && escape {
+			sb.WriteString((&url.URL{Path: t.text}).EscapedPath())
+		} else if t.kind == staticKind {
+			sb.WriteString(t.text)
 		} else {
-			// Not found
-			break
+			sb.WriteString(paramValues[i])
+			i++
 		}
 	}
+	return sb.String()
+}
 
-	if currentNode == nil {
-		return "", nil
+func (r *route[T]) params(paramValues []string, unescape bool) map[string]string {
+	params := make(map[string]string, len(paramValues)+len(r.defaults))
+	for name, v := range r.defaults {
+		params[name] = v
 	}
-	params := make(map[string]string)
 	for i, v := range paramValues {
-		params[currentNode.paramNames[i]] = v
+		if unescape {
+			if unescaped, err := url.PathUnescape(v); err == nil {
+				v = unescaped
+			}
+		}
+		params[r.paramNames[i]] = v
 	}
-	return currentNode.pristinePath, params
+	return params
 }
 
-func (m *PathMatcher) insert(path string, t kind, pristinePath string, paramNames []string) {
-	currentNode := m.tree
-	search := path
+// routes lists the routes ending at the node in matching order.
+func (n *node[T]) routes() []*route[T] {
+	routes := n.guarded
+	if n.pristinePath != "" {
+		routes = append(routes[:len(routes):len(routes)], &n.route)
+	}
+	return routes
+}
 
-	for {
-		searchLen := len(search)
-		prefixLen := len(currentNode.prefix)
-		lcpLen := 0
+func (n *node[T]) findRoute(predicates []routePredicate) *route[T] {
+	if len(predicates) == 0 {
+		if n.pristinePath == "" {
+			return nil
+		}
+		return &n.route
+	}
+	for _, r := range n.guarded {
+		if slices.Equal(r.predicates, predicates) {
+			return r
+		}
+	}
+	return nil
+}
+
+// boundary reports whether a route ending at the node before search ends on a segment boundary.
+func (n *node[T]) boundary(search string) bool {
+	return search[0] == '/' || (n.kind == staticKind && strings.HasSuffix(n.prefix, "/"))
+}
+
+func (n *node[T]) hasRoute() bool {
+	return n.pristinePath != "" || len(n.guarded) > 0
+}
+
+func (n *node[T]) hasPattern(path string) bool {
+	for _, r := range n.routes() {
+		if r.pristinePath == path {
+			return true
+		}
+	}
+	return false
+}
+
+// walk visits the node and its descendants in matching order until fn returns false.
+func (n *node[T]) walk(depth int, fn func(n *node[T], depth int) bool) bool {
+	if !fn(n, depth) {
+		return false
+	}
+	for _, child := range n.staticChildren {
+		if !child.walk(depth+1, fn) {
+			return false
+		}
+	}
+	for _, child := range n.paramChildren {
+		if !child.walk(depth+1, fn) {
+			return false
+		}
+	}
+	if n.anyChild != nil {
+		return n.anyChild.walk(depth+1, fn)
+	}
+	return true
+}
+
+func (n *node[T]) clone(parent *node[T]) *node[T] {
+	c := *n
+	c.parent = parent
+	c.staticChildren = nil
+	c.paramChildren = nil
+	c.anyChild = nil
+
+	for _, child := range n.staticChildren {
+		c.staticChildren = append(c.staticChildren, child.clone(&c))
+	}
+	for _, child := range n.paramChildren {
+		c.paramChildren = append(c.paramChildren, child.clone(&c))
+	}
+	if n.anyChild != nil {
+		c.anyChild = n.anyChild.clone(&c)
+	}
+
+	return &c
+}
+
+func (n *node[T]) insertStatic(path string) *node[T] {
+	currentNode := n
+
+	for path != "" {
+		child := currentNode.findStaticChild(path[0])
+		if child == nil {
+			child = newNode(staticKind, path, currentNode)
+			currentNode.addStaticChild(child)
+			return child
+		}
 
 		// LCP - Longest Common Prefix (https://en.wikipedia.org/wiki/LCP_array)
-		max := prefixLen
-		if searchLen < max {
-			max = searchLen
+		lcpLen := 0
+		max := len(child.prefix)
+		if len(path) < max {
+			max = len(path)
 		}
-		for ; lcpLen < max && search[lcpLen] == currentNode.prefix[lcpLen]; lcpLen++ {
+		for ; lcpLen < max && path[lcpLen] == child.prefix[lcpLen]; lcpLen++ {
 		}
 
-		if lcpLen == 0 {
-			// At root node
-			currentNode.prefix = search
-			if pristinePath != "" {
-				currentNode.kind = t
-				currentNode.paramNames = paramNames
-				currentNode.pristinePath = pristinePath
-			}
-		} else if lcpLen < prefixLen {
-			n := newNode(
-				currentNode.kind,
-				currentNode.prefix[lcpLen:],
-				currentNode,
-				currentNode.staticChildren,
-				currentNode.paramChild,
-				currentNode.anyChild,
-				currentNode.pristinePath,
-				currentNode.paramNames,
-			)
-			for _, child := range currentNode.staticChildren {
-				child.parent = n
-			}
-			if currentNode.paramChild != nil {
-				currentNode.paramChild.parent = n
-			}
-			if currentNode.anyChild != nil {
-				currentNode.anyChild.parent = n
-			}
+		if lcpLen < len(child.prefix) {
+			child.split(lcpLen)
+		}
 
-			// Reset parent node
-			currentNode.kind = staticKind
-			currentNode.prefix = currentNode.prefix[:lcpLen]
-			currentNode.staticChildren = nil
-			currentNode.pristinePath = ""
-			currentNode.paramNames = nil
-			currentNode.paramChild = nil
-			currentNode.anyChild = nil
-
-			// Only Static children could reach here
-			currentNode.addStaticChild(n)
-
-			if lcpLen == searchLen {
-				// At parent node
-				currentNode.kind = t
-				if pristinePath != "" {
-					currentNode.paramNames = paramNames
-					currentNode.pristinePath = pristinePath
-				}
-			} else {
-				// Create child node
-				n = newNode(t, search[lcpLen:], currentNode, nil, nil, nil, pristinePath, paramNames)
-				// Only Static children could reach here
-				currentNode.addStaticChild(n)
-			}
-		} else if lcpLen < searchLen {
-			search = search[lcpLen:]
-			c := currentNode.findChildWithLabel(search[0])
-			if c != nil {
-				// Go deeper
-				currentNode = c
-				continue
-			}
-			// Create child node
-			n := newNode(t, search, currentNode, nil, nil, nil, pristinePath, paramNames)
-			switch t {
-			case staticKind:
-				currentNode.addStaticChild(n)
-			case paramKind:
-				currentNode.paramChild = n
-			case anyKind:
-				currentNode.anyChild = n
-			}
-		} else {
-			// Node already exists
-			if pristinePath != "" {
-				currentNode.paramNames = paramNames
-				currentNode.pristinePath = pristinePath
-			}
+		path = path[lcpLen:]
+		currentNode = child
+	}
+
+	return currentNode
+}
+
+func (n *node[T]) findStatic(path string) *node[T] {
+	currentNode := n
+
+	for path != "" {
+		child := currentNode.findStaticChild(path[0])
+		if child == nil || !strings.HasPrefix(path, child.prefix) {
+			return nil
 		}
-		return
+
+		path = path[len(child.prefix):]
+		currentNode = child
 	}
+
+	return currentNode
 }
 
-func newNode(
-	kind kind,
-	prefix string,
-	parent *node,
-	staticChildren children,
-	paramChild *node,
-	anyChild *node,
-	pristinePath string,
-	paramNames []string,
-) *node {
-	return &node{
-		kind:           kind,
-		prefix:         prefix,
-		parent:         parent,
-		staticChildren: staticChildren,
-		paramChild:     paramChild,
-		anyChild:       anyChild,
-		pristinePath:   pristinePath,
-		paramNames:     paramNames,
+func (n *node[T]) split(at int) {
+	c := newNode(n.kind, n.prefix[at:], n)
+	c.staticChildren = n.staticChildren
+	c.paramChildren = n.paramChildren
+	c.anyChild = n.anyChild
+	c.route = n.route
+	c.guarded = n.guarded
+
+	for _, child := range c.staticChildren {
+		child.parent = c
+	}
+	for _, child := range c.paramChildren {
+		child.parent = c
+	}
+	if c.anyChild != nil {
+		c.anyChild.parent = c
 	}
+
+	// Reset parent node
+	n.prefix = n.prefix[:at]
+	n.staticChildren = children[T]{c}
+	n.paramChildren = nil
+	n.anyChild = nil
+	n.route = route[T]{}
+	n.guarded = nil
 }
 
-func (n *node) addStaticChild(c *node) {
+func (n *node[T]) addStaticChild(c *node[T]) {
 	n.staticChildren = append(n.staticChildren, c)
 }
 
-func (n *node) findChildWithLabel(l byte) *node {
-	if c := n.findStaticChild(l); c != nil {
-		return c
-	}
-	if l == paramLabel {
-		return n.paramChild
+func (n *node[T]) addParamChild(c *node[T]) {
+	// Constrained params are tried before the unconstrained one.
+	if c.constraint != nil {
+		for i, child := range n.paramChildren {
+			if child.constraint == nil {
+				n.paramChildren = append(n.paramChildren[:i], append(children[T]{c}, n.paramChildren[i:]...)...)
+				return
+			}
+		}
 	}
-	if l == anyLabel {
-		return n.anyChild
+	n.paramChildren = append(n.paramChildren, c)
+}
+
+func (n *node[T]) removeChild(c *node[T]) {
+	switch c.kind {
+	case staticKind:
+		n.staticChildren = n.staticChildren.remove(c)
+	case paramKind:
+		n.paramChildren = n.paramChildren.remove(c)
+	case anyKind:
+		n.anyChild = nil
 	}
-	return nil
 }
 
-func (n *node) findStaticChild(l byte) *node {
+func (n *node[T]) findStaticChild(l byte) *node[T] {
 	for _, c := range n.staticChildren {
 		if c.label() == l {
 			return c
@@ -448,10 +916,72 @@ func (n *node) findStaticChild(l byte) *node {
 	return nil
 }
 
-func (n *node) isLeaf() bool {
-	return len(n.staticChildren) == 0 && n.paramChild == nil && n.anyChild == nil
+func (n *node[T]) findParamChild(constraint *regexp.Regexp) *node[T] {
+	for _, c := range n.paramChildren {
+		if c.constraint == constraint || (c.constraint != nil && constraint != nil && c.constraint.String() == constraint.String()) {
+			return c
+		}
+	}
+	return nil
+}
+
+// swallows reports whether the node is a param ending an unguarded route, which matches all of the remaining path.
+func (n *node[T]) swallows() bool {
+	return n.kind == paramKind && n.isLeaf() && n.constraint == nil && n.pristinePath != ""
+}
+
+func (n *node[T]) isLeaf() bool {
+	return len(n.staticChildren) == 0 && len(n.paramChildren) == 0 && n.anyChild == nil
 }
 
-func (n *node) label() byte {
+func (n *node[T]) label() byte {
 	return n.prefix[0]
 }
+
+func (ctx *matchContext[T]) label(s string) byte {
+	c, _ := ctx.next(s)
+	return c
+}
+
+// next reads the first character of s as it is compared with static nodes, and the number of bytes it takes.
+func (ctx *matchContext[T]) next(s string) (byte, int) {
+	c, size := s[0], 1
+	if ctx.escaped && c == '%' && len(s) > 2 && isHex(s[1]) && isHex(s[2]) {
+		// an encoded slash is never a separator
+		if d := unhex(s[1])<<4 | unhex(s[2]); d != '/' {
+			c, size = d, 3
+		}
+	}
+	if ctx.caseInsensitive {
+		c = lowerASCII(c)
+	}
+	return c, size
+}
+
+func (ctx *matchContext[T]) hasPrefix(s string, prefix string) (int, bool) {
+	if !ctx.caseInsensitive && !ctx.escaped {
+		return len(prefix), strings.HasPrefix(s, prefix)
+	}
+
+	i := 0
+	for j := 0; j < len(prefix); j++ {
+		if i >= len(s) {
+			return 0, false
+		}
+		c, size := ctx.next(s[i:])
+		if c != prefix[j] {
+			return 0, false
+		}
+		i += size
+	}
+	return i, true
+}
+
+func (c children[T]) remove(n *node[T]) children[T] {
+	for i, child := range c {
+		if child == n {
+			return append(c[:i], c[i+1:]...)
+		}
+	}
+	return c
+}
@@ -0,0 +1,159 @@
+package util
+
+import (
+	"strings"
+)
+
+type (
+	PathMatcherOption func(o *pathMatcherOptions)
+
+	TrailingSlashPolicy uint8
+
+	pathMatcherOptions struct {
+		collapseSlashes bool
+		resolveDots     bool
+		caseInsensitive bool
+		escapedPath     bool
+		rawParams       bool
+		trailingSlash   TrailingSlashPolicy
+		segmentParams   bool
+	}
+)
+
+const (
+	TrailingSlashStrict TrailingSlashPolicy = iota
+	TrailingSlashIgnore
+	TrailingSlashRedirect
+)
+
+func WithCollapseSlashes() PathMatcherOption {
+	return func(o *pathMatcherOptions) {
+		o.collapseSlashes = true
+	}
+}
+
+func WithResolveDots() PathMatcherOption {
+	return func(o *pathMatcherOptions) {
+		o.resolveDots = true
+	}
+}
+
+func WithCaseInsensitive() PathMatcherOption {
+	return func(o *pathMatcherOptions) {
+		o.caseInsensitive = true
+	}
+}
+
+func WithEscapedPath() PathMatcherOption {
+	return func(o *pathMatcherOptions) {
+		o.escapedPath = true
+	}
+}
+
+func WithRawParams() PathMatcherOption {
+	return func(o *pathMatcherOptions) {
+		o.rawParams = true
+	}
+}
+
+func WithTrailingSlash(policy TrailingSlashPolicy) PathMatcherOption {
+	return func(o *pathMatcherOptions) {
+		o.trailingSlash = policy
+	}
+}
+
+func (o *pathMatcherOptions) normalize(path string) string {
+	if o.collapseSlashes {
+		path = collapseSlashes(path)
+	}
+	if o.resolveDots {
+		path = resolveDots(path)
+	}
+	return path
+}
+
+func (o *pathMatcherOptions) unescapeParams() bool {
+	return o.escapedPath && !o.rawParams
+}
+
+func (o *pathMatcherOptions) static(text string) string {
+	if !o.caseInsensitive {
+		return text
+	}
+	b := []byte(text)
+	for i, c := range b {
+		b[i] = lowerASCII(c)
+	}
+	return string(b)
+}
+
+func collapseSlashes(path string) string {
+	if !strings.Contains(path, "//") {
+		return path
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(path); i++ {
+		if path[i] == '/' && i > 0 && path[i-1] == '/' {
+			continue
+		}
+		sb.WriteByte(path[i])
+	}
+	return sb.String()
+}
+
+func resolveDots(path string) string {
+	if !strings.Contains("/"+path+"/", "/./") && !strings.Contains("/"+path+"/", "/../") {
+		return path
+	}
+
+	segments := strings.Split(path, "/")
+	resolved := make([]string, 0, len(segments))
+	for i, segment := range segments {
+		last := i == len(segments)-1
+		switch segment {
+		case ".":
+		case "..":
+			if n := len(resolved); n > 0 && !(n == 1 && resolved[0] == "") {
+				resolved = resolved[:n-1]
+			}
+		default:
+			resolved = append(resolved, segment)
+			continue
+		}
+		// A dot segment at the end still leaves a directory behind.
+		if last {
+			resolved = append(resolved, "")
+		}
+	}
+	return strings.Join(resolved, "/")
+}
+
+func toggleTrailingSlash(path string) string {
+	if strings.HasSuffix(path, "/") {
+		return path[:len(path)-1]
+	}
+	return path + "/"
+}
+
+func lowerASCII(c byte) byte {
+	if 'A' <= c && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
+
+func isHex(c byte) bool {
+	return ('0' <= c && c <= '9') || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')
+}
+
+func unhex(c byte) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	default:
+		return c - 'A' + 10
+	}
+}
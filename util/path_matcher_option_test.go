@@ -0,0 +1,183 @@
+package util
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPathMatcherOptions_Normalize(t *testing.T) {
+	testCases := []struct {
+		whenOptions []PathMatcherOption
+		whenPath    string
+		expectPath  string
+	}{
+		{
+			whenOptions: nil,
+			whenPath:    "/a//./b/",
+			expectPath:  "/a//./b/",
+		},
+		{
+			whenOptions: []PathMatcherOption{WithCollapseSlashes()},
+			whenPath:    "//a///b/",
+			expectPath:  "/a/b/",
+		},
+		{
+			whenOptions: []PathMatcherOption{WithResolveDots()},
+			whenPath:    "/a/./b/../c",
+			expectPath:  "/a/c",
+		},
+		{
+			whenOptions: []PathMatcherOption{WithResolveDots()},
+			whenPath:    "/a/b/..",
+			expectPath:  "/a/",
+		},
+		{
+			whenOptions: []PathMatcherOption{WithResolveDots()},
+			whenPath:    "/../a",
+			expectPath:  "/a",
+		},
+		{
+			whenOptions: []PathMatcherOption{WithCollapseSlashes(), WithResolveDots()},
+			whenPath:    "/a//.//b",
+			expectPath:  "/a/b",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.whenPath, func(t *testing.T) {
+			var o pathMatcherOptions
+			for _, option := range tc.whenOptions {
+				option(&o)
+			}
+			assert.Equal(t, tc.expectPath, o.normalize(tc.whenPath))
+		})
+	}
+}
+
+func TestPathMatcher_Canonical(t *testing.T) {
+	testCases := []struct {
+		whenOptions     []PathMatcherOption
+		whenPath        string
+		expectPath      string
+		expectCanonical string
+	}{
+		{
+			whenOptions:     nil,
+			whenPath:        "/users/1/posts/",
+			expectPath:      "",
+			expectCanonical: "",
+		},
+		{
+			whenOptions:     []PathMatcherOption{WithCollapseSlashes(), WithResolveDots()},
+			whenPath:        "/users//1/./posts",
+			expectPath:      "/users/:id/posts",
+			expectCanonical: "/users/1/posts",
+		},
+		{
+			whenOptions:     []PathMatcherOption{WithTrailingSlash(TrailingSlashIgnore)},
+			whenPath:        "/users/1/posts/",
+			expectPath:      "/users/:id/posts",
+			expectCanonical: "/users/1/posts/",
+		},
+		{
+			whenOptions:     []PathMatcherOption{WithTrailingSlash(TrailingSlashRedirect)},
+			whenPath:        "/users/1/posts/",
+			expectPath:      "/users/:id/posts",
+			expectCanonical: "/users/1/posts",
+		},
+		{
+			whenOptions:     []PathMatcherOption{WithTrailingSlash(TrailingSlashRedirect)},
+			whenPath:        "/docs",
+			expectPath:      "/docs/",
+			expectCanonical: "/docs/",
+		},
+		{
+			whenOptions:     []PathMatcherOption{WithCaseInsensitive()},
+			whenPath:        "/USERS/Ab/Posts",
+			expectPath:      "/users/:id/posts",
+			expectCanonical: "/users/Ab/posts",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.whenPath, func(t *testing.T) {
+			m := NewPathMatcher[struct{}](tc.whenOptions...)
+			m.Add("/users/:id/posts", struct{}{})
+			m.Add("/docs/", struct{}{})
+
+			_, path, _ := m.Match(tc.whenPath)
+			assert.Equal(t, tc.expectPath, path)
+
+			canonical, ok := m.Canonical(tc.whenPath)
+			assert.Equal(t, tc.expectPath != "", ok)
+			assert.Equal(t, tc.expectCanonical, canonical)
+		})
+	}
+}
+
+func TestPathMatcher_MatchEscaped(t *testing.T) {
+	testCases := []struct {
+		whenOptions []PathMatcherOption
+		whenPath    string
+		expectPath  string
+		expectParam map[string]string
+	}{
+		{
+			whenOptions: []PathMatcherOption{WithEscapedPath()},
+			whenPath:    "/objects/b/a%2Fc/meta",
+			expectPath:  "/objects/:bucket/:key/meta",
+			expectParam: map[string]string{"bucket": "b", "key": "a/c"},
+		},
+		{
+			whenOptions: []PathMatcherOption{WithEscapedPath(), WithRawParams()},
+			whenPath:    "/objects/b/a%2Fc/meta",
+			expectPath:  "/objects/:bucket/:key/meta",
+			expectParam: map[string]string{"bucket": "b", "key": "a%2Fc"},
+		},
+		{
+			whenOptions: []PathMatcherOption{WithEscapedPath()},
+			whenPath:    "/objects/b/a/c/meta",
+			expectPath:  "",
+		},
+		{
+			whenOptions: []PathMatcherOption{WithEscapedPath()},
+			whenPath:    "/%61%20b/%31",
+			expectPath:  "/a b/:id",
+			expectParam: map[string]string{"id": "1"},
+		},
+		{
+			whenOptions: []PathMatcherOption{WithEscapedPath()},
+			whenPath:    "/a%2Fb/1",
+			expectPath:  "",
+		},
+		{
+			whenOptions: nil,
+			whenPath:    "/a%20b/1",
+			expectPath:  "",
+		},
+		{
+			whenOptions: []PathMatcherOption{WithEscapedPath()},
+			whenPath:    "/files/a%2Fb",
+			expectPath:  "/files/:name<[a-z/]+>",
+			expectParam: map[string]string{"name": "a/b"},
+		},
+		{
+			whenOptions: []PathMatcherOption{WithEscapedPath(), WithRawParams()},
+			whenPath:    "/files/a%2Fb",
+			expectPath:  "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.whenPath, func(t *testing.T) {
+			m := NewPathMatcher[struct{}](tc.whenOptions...)
+			m.Add("/objects/:bucket/:key/meta", struct{}{})
+			m.Add("/a b/:id", struct{}{})
+			m.Add("/files/:name<[a-z/]+>", struct{}{})
+
+			_, path, param := m.Match(tc.whenPath)
+			assert.Equal(t, tc.expectPath, path)
+			assert.Equal(t, tc.expectParam, param)
+		})
+	}
+}
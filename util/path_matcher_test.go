@@ -1,7 +1,9 @@
 package util
 
 import (
+	"errors"
 	"github.com/stretchr/testify/assert"
+	"strings"
 	"testing"
 )
 
@@ -36,6 +38,16 @@ func TestMatchPath(t *testing.T) {
 			whenPattern: "/params/:foo/bar/:qux/*",
 			expectParam: map[string]string{"foo": "1", "qux": "2", "*": "any"},
 		},
+		{
+			whenPath:    "/tags/a>b/posts",
+			whenPattern: "/tags/:tag<[^/]+>/posts",
+			expectParam: map[string]string{"tag": "a>b"},
+		},
+		{
+			whenPath:    "/tags/a-b/posts",
+			whenPattern: "/tags/:tag<[^>/]+>/posts",
+			expectParam: map[string]string{"tag": "a-b"},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -47,8 +59,18 @@ func TestMatchPath(t *testing.T) {
 	}
 }
 
+func TestMatchPath_InvalidPattern(t *testing.T) {
+	for _, pattern := range []string{"/a/:id<[>", "/a/(:id", "/a/:"} {
+		assert.NotPanics(t, func() {
+			ok, params := MatchPath(pattern, "/a/1")
+			assert.False(t, ok)
+			assert.Nil(t, params)
+		})
+	}
+}
+
 func TestPathMatcher_Match(t *testing.T) {
-	m := NewPathMatcher()
+	m := NewPathMatcher[string]()
 
 	testCases := []struct {
 		whenPath    string
@@ -84,16 +106,204 @@ func TestPathMatcher_Match(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.whenPath, func(t *testing.T) {
-			m.Add(tc.expectPath)
-			path, param := m.Match(tc.whenPath)
+			m.Add(tc.expectPath, tc.expectPath)
+			value, path, param := m.Match(tc.whenPath)
+			assert.Equal(t, tc.expectPath, value)
+			assert.Equal(t, tc.expectPath, path)
+			assert.Equal(t, tc.expectParam, param)
+		})
+	}
+}
+
+func TestPathMatcher_MatchMultipleParams(t *testing.T) {
+	m := NewPathMatcher[struct{}]()
+
+	m.Add("/files/:name.:ext", struct{}{})
+	m.Add("/v:major.:minor/api", struct{}{})
+	m.Add("/date/:year-:month-:day", struct{}{})
+	m.Add("/date/:year<[0-9]{4}>", struct{}{})
+
+	testCases := []struct {
+		whenPath    string
+		expectPath  string
+		expectParam map[string]string
+	}{
+		{
+			whenPath:    "/files/a.txt",
+			expectPath:  "/files/:name.:ext",
+			expectParam: map[string]string{"name": "a", "ext": "txt"},
+		},
+		{
+			whenPath:    "/files/a.tar.gz",
+			expectPath:  "/files/:name.:ext",
+			expectParam: map[string]string{"name": "a.tar", "ext": "gz"},
+		},
+		{
+			whenPath:   "/files/a",
+			expectPath: "",
+		},
+		{
+			whenPath:    "/v1.2/api",
+			expectPath:  "/v:major.:minor/api",
+			expectParam: map[string]string{"major": "1", "minor": "2"},
+		},
+		{
+			whenPath:    "/date/2022-11-20",
+			expectPath:  "/date/:year-:month-:day",
+			expectParam: map[string]string{"year": "2022", "month": "11", "day": "20"},
+		},
+		{
+			whenPath:    "/date/2022",
+			expectPath:  "/date/:year<[0-9]{4}>",
+			expectParam: map[string]string{"year": "2022"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.whenPath, func(t *testing.T) {
+			_, path, param := m.Match(tc.whenPath)
 			assert.Equal(t, tc.expectPath, path)
 			assert.Equal(t, tc.expectParam, param)
 		})
 	}
+
+	assert.Len(t, m.MatchAll("/files/a.tar.gz"), 1)
+}
+
+func TestPathMatcher_MatchWildcard(t *testing.T) {
+	m := NewPathMatcher[struct{}]()
+
+	m.Add("/static/*filepath", struct{}{})
+	m.Add("/repos/*path/blob/:ref", struct{}{})
+	m.Add("/repos/*path/tree/:ref/*", struct{}{})
+	m.Add("/repos/*path", struct{}{})
+
+	testCases := []struct {
+		whenPath    string
+		expectPath  string
+		expectParam map[string]string
+	}{
+		{
+			whenPath:    "/static/css/main.css",
+			expectPath:  "/static/*filepath",
+			expectParam: map[string]string{"filepath": "css/main.css"},
+		},
+		{
+			whenPath:    "/repos/a/b/blob/main",
+			expectPath:  "/repos/*path/blob/:ref",
+			expectParam: map[string]string{"path": "a/b", "ref": "main"},
+		},
+		{
+			whenPath:    "/repos/a/blob/c/blob/main",
+			expectPath:  "/repos/*path/blob/:ref",
+			expectParam: map[string]string{"path": "a/blob/c", "ref": "main"},
+		},
+		{
+			whenPath:    "/repos/a/tree/main/src/util",
+			expectPath:  "/repos/*path/tree/:ref/*",
+			expectParam: map[string]string{"path": "a", "ref": "main", "*": "src/util"},
+		},
+		{
+			whenPath:    "/repos/a/b",
+			expectPath:  "/repos/*path",
+			expectParam: map[string]string{"path": "a/b"},
+		},
+		{
+			whenPath:    "/repos/blob/main",
+			expectPath:  "/repos/*path",
+			expectParam: map[string]string{"path": "blob/main"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.whenPath, func(t *testing.T) {
+			_, path, param := m.Match(tc.whenPath)
+			assert.Equal(t, tc.expectPath, path)
+			assert.Equal(t, tc.expectParam, param)
+		})
+	}
+}
+
+func TestPathMatcher_MatchOptional(t *testing.T) {
+	m := NewPathMatcher[struct{}]()
+
+	m.Add("/list(/:page=1(/:size))", struct{}{})
+	m.Add("/users/:id?/posts", struct{}{})
+
+	testCases := []struct {
+		whenPath    string
+		expectPath  string
+		expectParam map[string]string
+	}{
+		{
+			whenPath:    "/list",
+			expectPath:  "/list(/:page=1(/:size))",
+			expectParam: map[string]string{"page": "1"},
+		},
+		{
+			whenPath:    "/list/2",
+			expectPath:  "/list(/:page=1(/:size))",
+			expectParam: map[string]string{"page": "2"},
+		},
+		{
+			whenPath:    "/list/2/10",
+			expectPath:  "/list(/:page=1(/:size))",
+			expectParam: map[string]string{"page": "2", "size": "10"},
+		},
+		{
+			whenPath:    "/users/posts",
+			expectPath:  "/users/:id?/posts",
+			expectParam: map[string]string{},
+		},
+		{
+			whenPath:    "/users/1/posts",
+			expectPath:  "/users/:id?/posts",
+			expectParam: map[string]string{"id": "1"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.whenPath, func(t *testing.T) {
+			_, path, param := m.Match(tc.whenPath)
+			assert.Equal(t, tc.expectPath, path)
+			assert.Equal(t, tc.expectParam, param)
+		})
+	}
+
+	assert.True(t, m.Remove("/list(/:page=1(/:size))"))
+	for _, path := range []string{"/list", "/list/2", "/list/2/10"} {
+		_, res, _ := m.Match(path)
+		assert.Equal(t, "", res)
+	}
+	assert.False(t, m.Remove("/list(/:page=1(/:size))"))
+}
+
+func TestPathMatcher_Value(t *testing.T) {
+	m := NewPathMatcher[int]()
+
+	m.Add("/users", 1)
+	m.Add("/users/:id", 2)
+
+	value, path, param := m.Match("/users/1")
+	assert.Equal(t, 2, value)
+	assert.Equal(t, "/users/:id", path)
+	assert.Equal(t, map[string]string{"id": "1"}, param)
+
+	m.Add("/users/:id", 3)
+
+	value, _, _ = m.Match("/users/1")
+	assert.Equal(t, 3, value)
+
+	assert.True(t, m.Remove("/users/:id"))
+
+	value, path, param = m.Match("/users/1")
+	assert.Equal(t, 0, value)
+	assert.Equal(t, "", path)
+	assert.Nil(t, param)
 }
 
 func TestPathMatcher_Remove(t *testing.T) {
-	m := NewPathMatcher()
+	m := NewPathMatcher[string]()
 
 	testCases := []struct {
 		whenPath string
@@ -116,12 +326,394 @@ func TestPathMatcher_Remove(t *testing.T) {
 		{
 			whenPath: "/params/:foo/:bar/:qux",
 		},
+		{
+			whenPath: "/params/:foo<[0-9]+>",
+		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.whenPath, func(t *testing.T) {
-			m.Add(tc.whenPath)
+			m.Add(tc.whenPath, tc.whenPath)
 			assert.True(t, m.Remove(tc.whenPath))
 		})
 	}
 }
+
+func TestPathMatcher_MatchConstraint(t *testing.T) {
+	m := NewPathMatcher[struct{}]()
+
+	m.Add("/users/me", struct{}{})
+	m.Add("/users/:id<[0-9]+>", struct{}{})
+	m.Add("/users/:name", struct{}{})
+	m.Add("/users/:id<[0-9]+>/posts", struct{}{})
+	m.Add("/files/:name<[a-z]+\\.txt>", struct{}{})
+	m.Add("/files/*", struct{}{})
+
+	testCases := []struct {
+		whenPath    string
+		expectPath  string
+		expectParam map[string]string
+	}{
+		{
+			whenPath:    "/users/me",
+			expectPath:  "/users/me",
+			expectParam: map[string]string{},
+		},
+		{
+			whenPath:    "/users/1",
+			expectPath:  "/users/:id<[0-9]+>",
+			expectParam: map[string]string{"id": "1"},
+		},
+		{
+			whenPath:    "/users/foo",
+			expectPath:  "/users/:name",
+			expectParam: map[string]string{"name": "foo"},
+		},
+		{
+			whenPath:    "/users/1/posts",
+			expectPath:  "/users/:id<[0-9]+>/posts",
+			expectParam: map[string]string{"id": "1"},
+		},
+		{
+			whenPath:    "/files/a.txt",
+			expectPath:  "/files/:name<[a-z]+\\.txt>",
+			expectParam: map[string]string{"name": "a.txt"},
+		},
+		{
+			whenPath:    "/files/a.png",
+			expectPath:  "/files/*",
+			expectParam: map[string]string{"*": "a.png"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.whenPath, func(t *testing.T) {
+			_, path, param := m.Match(tc.whenPath)
+			assert.Equal(t, tc.expectPath, path)
+			assert.Equal(t, tc.expectParam, param)
+		})
+	}
+}
+
+func TestPathMatcher_Build(t *testing.T) {
+	m := NewPathMatcher[struct{}]()
+
+	m.Add("/params/:foo/bar/:qux", struct{}{})
+
+	path, err := m.Build("/params/:foo/bar/:qux", map[string]string{"foo": "1", "qux": "2"})
+	assert.NoError(t, err)
+	assert.Equal(t, "/params/1/bar/2", path)
+
+	_, p, param := m.Match(path)
+	assert.Equal(t, "/params/:foo/bar/:qux", p)
+	assert.Equal(t, map[string]string{"foo": "1", "qux": "2"}, param)
+
+	_, err = m.Build("/params/:foo", map[string]string{"foo": "1"})
+	assert.ErrorIs(t, err, ErrUnknownPattern)
+}
+
+func TestPathMatcher_AddE(t *testing.T) {
+	testCases := []struct {
+		whenPaths      []string
+		expectPattern  string
+		expectExisting string
+		expectErr      error
+	}{
+		{
+			whenPaths: []string{"/users", "/users/:id", "/users/:id/posts", "/users/:id<[0-9]+>", "/users/*"},
+		},
+		{
+			whenPaths:      []string{"/users/:id", "/users/*"},
+			expectPattern:  "/users/*",
+			expectExisting: "/users/:id",
+			expectErr:      ErrRouteConflict,
+		},
+		{
+			whenPaths:      []string{"/users/*/meta", "/users/:id"},
+			expectPattern:  "/users/:id",
+			expectExisting: "/users/*/meta",
+			expectErr:      ErrRouteConflict,
+		},
+		{
+			whenPaths: []string{"/users/*", "/users/:id<[0-9]+>"},
+		},
+		{
+			whenPaths:      []string{"/users/:id", "/users/:id"},
+			expectPattern:  "/users/:id",
+			expectExisting: "/users/:id",
+			expectErr:      ErrRouteConflict,
+		},
+		{
+			whenPaths:      []string{"/a/:x", "/a/:y"},
+			expectPattern:  "/a/:y",
+			expectExisting: "/a/:x",
+			expectErr:      ErrRouteConflict,
+		},
+		{
+			whenPaths: []string{"/static/*", "/static/*/any"},
+		},
+		{
+			whenPaths:      []string{"/static/*", "/static/*filepath"},
+			expectPattern:  "/static/*filepath",
+			expectExisting: "/static/*",
+			expectErr:      ErrRouteConflict,
+		},
+		{
+			whenPaths:      []string{"/list", "/list(/:page)"},
+			expectPattern:  "/list(/:page)",
+			expectExisting: "/list",
+			expectErr:      ErrRouteConflict,
+		},
+		{
+			whenPaths:     []string{"/static/*:id"},
+			expectPattern: "/static/*:id",
+			expectErr:     ErrRouteConflict,
+		},
+		{
+			whenPaths:     []string{"/users/:a:b"},
+			expectPattern: "/users/:a:b",
+			expectErr:     ErrRouteConflict,
+		},
+		{
+			whenPaths: []string{"/users/:"},
+			expectErr: ErrInvalidPattern,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(strings.Join(tc.whenPaths, ","), func(t *testing.T) {
+			m := NewPathMatcher[struct{}]()
+
+			var err error
+			for _, path := range tc.whenPaths {
+				if err = m.AddE(path, struct{}{}); err != nil {
+					break
+				}
+			}
+
+			if tc.expectErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tc.expectErr)
+
+			var conflict *RouteConflictError
+			if errors.As(err, &conflict) {
+				assert.Equal(t, tc.expectPattern, conflict.Pattern)
+				assert.Equal(t, tc.expectExisting, conflict.Existing)
+			}
+		})
+	}
+}
+
+func TestPathMatcher_MatchAll(t *testing.T) {
+	m := NewPathMatcher[int]()
+
+	m.Add("/users/me", 1)
+	m.Add("/users/:id<[0-9]+>", 2)
+	m.Add("/users/:name", 3)
+	m.Add("/users/*", 4)
+	m.Add("/posts/:id", 5)
+
+	testCases := []struct {
+		whenPath      string
+		expectMatches []PathMatch[int]
+	}{
+		{
+			whenPath: "/users/me",
+			expectMatches: []PathMatch[int]{
+				{Value: 1, Pattern: "/users/me", Params: map[string]string{}},
+				{Value: 3, Pattern: "/users/:name", Params: map[string]string{"name": "me"}},
+				{Value: 4, Pattern: "/users/*", Params: map[string]string{"*": "me"}},
+			},
+		},
+		{
+			whenPath: "/users/1",
+			expectMatches: []PathMatch[int]{
+				{Value: 2, Pattern: "/users/:id<[0-9]+>", Params: map[string]string{"id": "1"}},
+				{Value: 3, Pattern: "/users/:name", Params: map[string]string{"name": "1"}},
+				{Value: 4, Pattern: "/users/*", Params: map[string]string{"*": "1"}},
+			},
+		},
+		{
+			whenPath:      "/comments/1",
+			expectMatches: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.whenPath, func(t *testing.T) {
+			assert.Equal(t, tc.expectMatches, m.MatchAll(tc.whenPath))
+		})
+	}
+}
+
+func TestPathMatcher_Walk(t *testing.T) {
+	m := NewPathMatcher[int]()
+
+	m.Add("/users/me", 1)
+	m.Add("/users/:id<[0-9]+>", 2)
+	m.Add("/posts(/:page)", 3)
+	m.Add("/files/*path", 4)
+
+	assert.Equal(t, []string{"/users/me", "/users/:id<[0-9]+>", "/posts(/:page)", "/files/*path"}, m.Routes())
+
+	var paramNames [][]string
+	m.Walk(func(pattern string, names []string) bool {
+		paramNames = append(paramNames, names)
+		return len(paramNames) < 3
+	})
+	assert.Equal(t, [][]string{nil, {"id"}, {"page"}}, paramNames)
+
+	assert.Equal(t, ""+
+		"static \"/\"\n"+
+		"  static \"users/\"\n"+
+		"    static \"me\" -> /users/me\n"+
+		"    param \":\" <[0-9]+> -> /users/:id<[0-9]+>\n"+
+		"  static \"posts\" -> /posts(/:page)\n"+
+		"    static \"/\"\n"+
+		"      param \":\" -> /posts(/:page)\n"+
+		"  static \"files/\"\n"+
+		"    any \"*\" -> /files/*path\n", m.String())
+}
+
+func TestPathMatcher_MatchInto(t *testing.T) {
+	m := NewPathMatcher[int]()
+
+	m.Add("/static", 1)
+	m.Add("/users/:id/posts/:post", 2)
+	m.Add("/list(/:page=1)", 3)
+
+	var params Params
+
+	value, pattern := m.MatchInto("/users/1/posts/2", &params)
+	assert.Equal(t, 2, value)
+	assert.Equal(t, "/users/:id/posts/:post", pattern)
+	assert.Equal(t, 2, params.Len())
+	assert.Equal(t, "1", params.Get("id"))
+	assert.Equal(t, "2", params.Get("post"))
+
+	value, pattern = m.MatchInto("/list", &params)
+	assert.Equal(t, 3, value)
+	assert.Equal(t, "/list(/:page=1)", pattern)
+	assert.Equal(t, 1, params.Len())
+	assert.Equal(t, "1", params.Get("page"))
+
+	value, pattern = m.MatchInto("/static", &params)
+	assert.Equal(t, 1, value)
+	assert.Equal(t, "/static", pattern)
+	assert.Equal(t, 0, params.Len())
+
+	value, pattern = m.MatchInto("/unknown", &params)
+	assert.Equal(t, 0, value)
+	assert.Equal(t, "", pattern)
+	assert.Equal(t, 0, params.Len())
+}
+
+func BenchmarkPathMatcher_MatchInto(b *testing.B) {
+	m := NewPathMatcher[int]()
+
+	m.Add("/static/path", 1)
+	m.Add("/users/:id", 2)
+	m.Add("/users/:id/posts/:post", 3)
+	m.Add("/files/*", 4)
+
+	benchmarks := []struct {
+		name string
+		path string
+	}{
+		{name: "static", path: "/static/path"},
+		{name: "param", path: "/users/1"},
+		{name: "params", path: "/users/1/posts/2"},
+		{name: "any", path: "/files/a/b"},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			var params Params
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				m.MatchInto(bm.path, &params)
+			}
+		})
+	}
+}
+
+func TestPathMatcher_MatchPrefix(t *testing.T) {
+	m := NewPathMatcher[string]()
+
+	m.Add("/static", "static")
+	m.Add("/static/", "slash")
+	m.Add("/api", "api")
+	m.Add("/api/v1/users/:id", "user")
+	m.Add("/proxy/:host", "proxy")
+
+	testCases := []struct {
+		whenPath      string
+		expectValue   string
+		expectPattern string
+		expectParams  map[string]string
+		expectRest    string
+	}{
+		{
+			whenPath:      "/static",
+			expectValue:   "static",
+			expectPattern: "/static",
+			expectParams:  map[string]string{},
+			expectRest:    "/",
+		},
+		{
+			whenPath:      "/static/css/a.css",
+			expectValue:   "slash",
+			expectPattern: "/static/",
+			expectParams:  map[string]string{},
+			expectRest:    "/css/a.css",
+		},
+		{
+			whenPath:      "/static/",
+			expectValue:   "slash",
+			expectPattern: "/static/",
+			expectParams:  map[string]string{},
+			expectRest:    "/",
+		},
+		{
+			whenPath:      "/api/v1/users/1/posts",
+			expectValue:   "user",
+			expectPattern: "/api/v1/users/:id",
+			expectParams:  map[string]string{"id": "1"},
+			expectRest:    "/posts",
+		},
+		{
+			whenPath:      "/api/v2/users",
+			expectValue:   "api",
+			expectPattern: "/api",
+			expectParams:  map[string]string{},
+			expectRest:    "/v2/users",
+		},
+		{
+			whenPath:      "/proxy/example.com/a/b",
+			expectValue:   "proxy",
+			expectPattern: "/proxy/:host",
+			expectParams:  map[string]string{"host": "example.com"},
+			expectRest:    "/a/b",
+		},
+		{
+			whenPath:      "/apis",
+			expectValue:   "",
+			expectPattern: "",
+			expectParams:  nil,
+			expectRest:    "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.whenPath, func(t *testing.T) {
+			value, pattern, params, rest := m.MatchPrefix(tc.whenPath)
+			assert.Equal(t, tc.expectValue, value)
+			assert.Equal(t, tc.expectPattern, pattern)
+			assert.Equal(t, tc.expectParams, params)
+			assert.Equal(t, tc.expectRest, rest)
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package util
+
+type (
+	Params struct {
+		names  []string
+		values []string
+	}
+)
+
+func (p *Params) Get(name string) string {
+	for i, n := range p.names {
+		if n == name {
+			return p.values[i]
+		}
+	}
+	return ""
+}
+
+func (p *Params) Len() int {
+	return len(p.names)
+}
+
+func (p *Params) Range(fn func(name string, value string) bool) {
+	for i, name := range p.names {
+		if !fn(name, p.values[i]) {
+			return
+		}
+	}
+}
+
+// Reset empties the params but keeps their buffers, so they can be put back into a sync.Pool.
+func (p *Params) Reset() {
+	p.names = p.names[:0]
+	p.values = p.values[:0]
+}
@@ -0,0 +1,25 @@
+package util
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParams(t *testing.T) {
+	p := Params{names: []string{"id", "post"}, values: []string{"1", "2"}}
+
+	assert.Equal(t, 2, p.Len())
+	assert.Equal(t, "1", p.Get("id"))
+	assert.Equal(t, "", p.Get("unknown"))
+
+	var names []string
+	p.Range(func(name string, value string) bool {
+		names = append(names, name)
+		return false
+	})
+	assert.Equal(t, []string{"id"}, names)
+
+	p.Reset()
+	assert.Equal(t, 0, p.Len())
+	assert.Equal(t, "", p.Get("id"))
+}
@@ -0,0 +1,446 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"golang.org/x/exp/slices"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+type (
+	pathToken struct {
+		kind         kind
+		text         string
+		name         string
+		constraint   *regexp.Regexp
+		defaultValue string
+		hasDefault   bool
+	}
+
+	pathVariant struct {
+		tokens   []pathToken
+		defaults map[string]string
+	}
+
+	patternParser struct {
+		pattern string
+		pos     int
+	}
+)
+
+var (
+	ErrInvalidPattern = errors.New("invalid pattern")
+	ErrUnknownPattern = errors.New("unknown pattern")
+	ErrMissingParam   = errors.New("missing param")
+	ErrUnknownParam   = errors.New("unknown param")
+	ErrInvalidParam   = errors.New("invalid param")
+	ErrRouteConflict  = errors.New("route conflict")
+)
+
+func BuildPath(pattern string, params map[string]string) (string, error) {
+	variants, err := parsePattern(pattern)
+	if err != nil {
+		return "", err
+	}
+	return buildPath(pattern, variants, params)
+}
+
+func parsePattern(pattern string) ([]pathVariant, error) {
+	p := &patternParser{pattern: pattern}
+
+	variants, err := p.parseSequence(0)
+	if err != nil {
+		return nil, err
+	}
+
+	// Optional parts may expand to the same route more than once, keep the first one.
+	var unique []pathVariant
+	shapes := map[string]bool{}
+	for _, v := range variants {
+		if shape := v.shape(); !shapes[shape] {
+			shapes[shape] = true
+			unique = append(unique, v)
+		}
+	}
+	return unique, nil
+}
+
+func (p *patternParser) parseSequence(depth int) ([]pathVariant, error) {
+	var items [][]pathVariant
+	var static strings.Builder
+
+	flush := func() {
+		if static.Len() > 0 {
+			items = append(items, []pathVariant{{tokens: []pathToken{{kind: staticKind, text: static.String()}}}})
+			static.Reset()
+		}
+	}
+
+	for p.pos < len(p.pattern) {
+		c := p.pattern[p.pos]
+		switch c {
+		case '\\':
+			if p.pos+1 < len(p.pattern) && isPatternLabel(p.pattern[p.pos+1]) {
+				p.pos++
+			}
+			static.WriteByte(p.pattern[p.pos])
+			p.pos++
+		case '(':
+			flush()
+
+			start := p.pos
+			p.pos++
+			group, err := p.parseSequence(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			if p.pos >= len(p.pattern) {
+				return nil, fmt.Errorf("%w: %q has an unterminated group at %d", ErrInvalidPattern, p.pattern, start)
+			}
+			p.pos++
+
+			items = append(items, optionalVariants(group))
+		case ')':
+			if depth == 0 {
+				return nil, fmt.Errorf("%w: %q has an unbalanced group at %d", ErrInvalidPattern, p.pattern, p.pos)
+			}
+			flush()
+			return concatVariants(items), nil
+		case paramLabel:
+			flush()
+
+			token, err := p.parseParam()
+			if err != nil {
+				return nil, err
+			}
+
+			optional := p.pos < len(p.pattern) && p.pattern[p.pos] == '?'
+			if optional {
+				p.pos++
+			}
+			if (optional || depth > 0) && p.pos < len(p.pattern) && p.pattern[p.pos] == '=' {
+				p.pos++
+				token.defaultValue = p.parseDefault()
+				token.hasDefault = true
+			}
+
+			item := []pathVariant{{tokens: []pathToken{token}}}
+			if optional {
+				// ":name?" is a shorthand of "(/:name)", the leading slash goes away with the param.
+				if last := len(items) - 1; last >= 0 && len(items[last]) == 1 && len(items[last][0].tokens) == 1 {
+					if t := items[last][0].tokens[0]; t.kind == staticKind && strings.HasSuffix(t.text, "/") {
+						if t.text == "/" {
+							items = items[:last]
+						} else {
+							items[last] = []pathVariant{{tokens: []pathToken{{kind: staticKind, text: t.text[:len(t.text)-1]}}}}
+						}
+						item = concatVariants([][]pathVariant{{{tokens: []pathToken{{kind: staticKind, text: "/"}}}}, item})
+					}
+				}
+				item = optionalVariants(item)
+			}
+			items = append(items, item)
+		case anyLabel:
+			flush()
+
+			p.pos++
+			name := p.parseName()
+			if name == "" {
+				name = string(anyLabel)
+			}
+			items = append(items, []pathVariant{{tokens: []pathToken{{kind: anyKind, name: name}}}})
+		default:
+			static.WriteByte(c)
+			p.pos++
+		}
+	}
+
+	if depth > 0 {
+		return nil, fmt.Errorf("%w: %q has an unterminated group", ErrInvalidPattern, p.pattern)
+	}
+	flush()
+	return concatVariants(items), nil
+}
+
+func (p *patternParser) parseParam() (pathToken, error) {
+	start := p.pos
+	p.pos++
+
+	name := p.parseName()
+	if name == "" {
+		return pathToken{}, fmt.Errorf("%w: %q has an unnamed parameter at %d", ErrInvalidPattern, p.pattern, start)
+	}
+	token := pathToken{kind: paramKind, name: name}
+
+	if p.pos < len(p.pattern) && p.pattern[p.pos] == '<' {
+		end, err := scanConstraint(p.pattern, p.pos)
+		if err != nil {
+			return pathToken{}, err
+		}
+		constraint, err := regexp.Compile("^(?:" + p.pattern[p.pos+1:end] + ")$")
+		if err != nil {
+			return pathToken{}, fmt.Errorf("%w: %q has an invalid constraint: %s", ErrInvalidPattern, p.pattern, err)
+		}
+		token.constraint = constraint
+		p.pos = end + 1
+	}
+
+	return token, nil
+}
+
+func (p *patternParser) parseName() string {
+	start := p.pos
+	for ; p.pos < len(p.pattern) && isParamNameChar(p.pattern[p.pos]); p.pos++ {
+	}
+	return p.pattern[start:p.pos]
+}
+
+func (p *patternParser) parseDefault() string {
+	var sb strings.Builder
+	for ; p.pos < len(p.pattern); p.pos++ {
+		c := p.pattern[p.pos]
+		if c == '\\' && p.pos+1 < len(p.pattern) {
+			p.pos++
+			sb.WriteByte(p.pattern[p.pos])
+			continue
+		}
+		if c == '/' || c == '(' || c == ')' || c == '?' {
+			break
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
+}
+
+func optionalVariants(variants []pathVariant) []pathVariant {
+	omitted := pathVariant{}
+	for _, v := range variants {
+		for _, t := range v.tokens {
+			if t.hasDefault {
+				if omitted.defaults == nil {
+					omitted.defaults = map[string]string{}
+				}
+				omitted.defaults[t.name] = t.defaultValue
+			}
+		}
+	}
+	return append(variants, omitted)
+}
+
+func concatVariants(items [][]pathVariant) []pathVariant {
+	variants := []pathVariant{{}}
+	for _, item := range items {
+		next := make([]pathVariant, 0, len(variants)*len(item))
+		for _, v := range variants {
+			for _, w := range item {
+				next = append(next, v.concat(w))
+			}
+		}
+		variants = next
+	}
+	return variants
+}
+
+func (v pathVariant) concat(other pathVariant) pathVariant {
+	var res pathVariant
+
+	res.tokens = append(res.tokens, v.tokens...)
+	for _, t := range other.tokens {
+		if last := len(res.tokens) - 1; last >= 0 && t.kind == staticKind && res.tokens[last].kind == staticKind {
+			res.tokens[last].text += t.text
+			continue
+		}
+		res.tokens = append(res.tokens, t)
+	}
+
+	for _, defaults := range []map[string]string{v.defaults, other.defaults} {
+		for name, value := range defaults {
+			if res.defaults == nil {
+				res.defaults = map[string]string{}
+			}
+			res.defaults[name] = value
+		}
+	}
+
+	return res
+}
+
+func (v pathVariant) shape() string {
+	var sb strings.Builder
+	for _, t := range v.tokens {
+		switch t.kind {
+		case staticKind:
+			sb.WriteString(t.text)
+		case paramKind:
+			sb.WriteByte(paramLabel)
+			if t.constraint != nil {
+				sb.WriteString(t.constraint.String())
+			}
+		case anyKind:
+			sb.WriteByte(anyLabel)
+		}
+		sb.WriteByte(0)
+	}
+	return sb.String()
+}
+
+func (v pathVariant) paramNames() []string {
+	var paramNames []string
+	for _, t := range v.tokens {
+		if t.kind != staticKind {
+			paramNames = append(paramNames, t.name)
+		}
+	}
+	return paramNames
+}
+
+func buildPath(pattern string, variants []pathVariant, params map[string]string) (string, error) {
+	// A variant taking the params as they are wins, then one filling the missing params with their defaults.
+	for _, withDefaults := range []bool{false, true} {
+		for _, v := range variants {
+			if v.accepts(params, withDefaults) {
+				return v.build(pattern, params)
+			}
+		}
+	}
+	return variants[0].build(pattern, params)
+}
+
+func (v pathVariant) accepts(params map[string]string, withDefaults bool) bool {
+	paramNames := v.paramNames()
+	for _, t := range v.tokens {
+		if t.kind == staticKind {
+			continue
+		}
+		if _, ok := params[t.name]; !ok && !(withDefaults && t.hasDefault) {
+			return false
+		}
+	}
+	for name := range params {
+		if _, ok := v.defaults[name]; !ok && !slices.Contains(paramNames, name) {
+			return false
+		}
+	}
+	return true
+}
+
+func (v pathVariant) build(pattern string, params map[string]string) (string, error) {
+	var sb strings.Builder
+	var missing []string
+	used := make(map[string]bool, len(params))
+	for name := range v.defaults {
+		if _, ok := params[name]; ok {
+			used[name] = true
+		}
+	}
+
+	for _, t := range v.tokens {
+		if t.kind == staticKind {
+			sb.WriteString(t.text)
+			continue
+		}
+
+		value, ok := params[t.name]
+		if ok {
+			used[t.name] = true
+		} else if t.hasDefault {
+			value = t.defaultValue
+		} else {
+			missing = append(missing, t.name)
+			continue
+		}
+
+		if t.constraint != nil && !t.constraint.MatchString(value) {
+			return "", fmt.Errorf("%w: %q does not match the constraint of %q in %q", ErrInvalidParam, value, t.name, pattern)
+		}
+
+		if t.kind == anyKind {
+			segments := strings.Split(value, "/")
+			for i, segment := range segments {
+				segments[i] = url.PathEscape(segment)
+			}
+			sb.WriteString(strings.Join(segments, "/"))
+		} else {
+			sb.WriteString(url.PathEscape(value))
+		}
+	}
+
+	if len(missing) > 0 {
+		return "", fmt.Errorf("%w: %s in %q", ErrMissingParam, strings.Join(missing, ", "), pattern)
+	}
+	if len(used) < len(params) {
+		var unknown []string
+		for name := range params {
+			if !used[name] {
+				unknown = append(unknown, name)
+			}
+		}
+		sort.Strings(unknown)
+		return "", fmt.Errorf("%w: %s in %q", ErrUnknownParam, strings.Join(unknown, ", "), pattern)
+	}
+
+	return sb.String(), nil
+}
+
+func isPatternLabel(c byte) bool {
+	return c == paramLabel || c == anyLabel || c == '(' || c == ')' || c == '?'
+}
+
+func isParamNameChar(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
+
+func scanConstraint(pattern string, start int) (int, error) {
+	depth := 0
+	for i := start; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			i++
+		case '[':
+			// A > inside a character class, like <[^>]+>, does not end the constraint.
+			if end := scanCharClass(pattern, i); end >= 0 {
+				i = end
+			} else {
+				i = len(pattern)
+			}
+		case '<':
+			depth++
+		case '>':
+			depth--
+			if depth == 0 {
+				return i, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("%w: %q has an unterminated constraint at %d", ErrInvalidPattern, pattern, start)
+}
+
+// scanCharClass returns the index of the ] closing the character class opened at start, or -1. A ] right after the
+// opening [ or [^ is a literal, as in regular expressions.
+func scanCharClass(s string, start int) int {
+	i := start + 1
+	if i < len(s) && s[i] == '^' {
+		i++
+	}
+	if i < len(s) && s[i] == ']' {
+		i++
+	}
+	for ; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '[':
+			if i+1 < len(s) && s[i+1] == ':' {
+				if end := strings.Index(s[i+2:], ":]"); end >= 0 {
+					i += end + 3
+				}
+			}
+		case ']':
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,222 @@
+package util
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestParsePattern(t *testing.T) {
+	testCases := []struct {
+		whenPattern string
+		expectNames []string
+		expectErr   bool
+	}{
+		{
+			whenPattern: "/static",
+			expectNames: nil,
+		},
+		{
+			whenPattern: "/params/:foo/bar/:qux/*",
+			expectNames: []string{"foo", "qux", "*"},
+		},
+		{
+			whenPattern: "/params/\\:foo",
+			expectNames: nil,
+		},
+		{
+			whenPattern: "/users/:id<[0-9]{1,3}>/:name<(?P<v>[a-z]+)>",
+			expectNames: []string{"id", "name"},
+		},
+		{
+			whenPattern: "/tags/:tag<[^>]+>/:name<[]>[:alpha:]]+>",
+			expectNames: []string{"tag", "name"},
+		},
+		{
+			whenPattern: "/repos/*path/blob/:ref",
+			expectNames: []string{"path", "ref"},
+		},
+		{
+			whenPattern: "/date/:year-:month-:day",
+			expectNames: []string{"year", "month", "day"},
+		},
+		{
+			whenPattern: "/files/:name.:ext",
+			expectNames: []string{"name", "ext"},
+		},
+		{
+			whenPattern: "/users/:",
+			expectErr:   true,
+		},
+		{
+			whenPattern: "/list(/:page",
+			expectErr:   true,
+		},
+		{
+			whenPattern: "/list/:page)",
+			expectErr:   true,
+		},
+		{
+			whenPattern: "/users/:id<[0-9]+",
+			expectErr:   true,
+		},
+		{
+			whenPattern: "/users/:id<[0-9+>",
+			expectErr:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.whenPattern, func(t *testing.T) {
+			variants, err := parsePattern(tc.whenPattern)
+			if tc.expectErr {
+				assert.ErrorIs(t, err, ErrInvalidPattern)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectNames, variants[0].paramNames())
+		})
+	}
+}
+
+func TestParsePattern_Optional(t *testing.T) {
+	testCases := []struct {
+		whenPattern    string
+		expectShapes   []string
+		expectDefaults []map[string]string
+	}{
+		{
+			whenPattern:    "/list(/:page(/:size))",
+			expectShapes:   []string{"/list/:/:", "/list/:", "/list"},
+			expectDefaults: []map[string]string{nil, nil, nil},
+		},
+		{
+			whenPattern:    "/list(/:page=1(/:size=20))",
+			expectShapes:   []string{"/list/:/:", "/list/:", "/list"},
+			expectDefaults: []map[string]string{nil, {"size": "20"}, {"page": "1", "size": "20"}},
+		},
+		{
+			whenPattern:    "/list/:page?/:size?=20",
+			expectShapes:   []string{"/list/:/:", "/list/:", "/list"},
+			expectDefaults: []map[string]string{nil, {"size": "20"}, {"size": "20"}},
+		},
+		{
+			whenPattern:    "/users/:id?/posts",
+			expectShapes:   []string{"/users/:/posts", "/users/posts"},
+			expectDefaults: []map[string]string{nil, nil},
+		},
+		{
+			whenPattern:    "/files\\(1\\)",
+			expectShapes:   []string{"/files(1)"},
+			expectDefaults: []map[string]string{nil},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.whenPattern, func(t *testing.T) {
+			variants, err := parsePattern(tc.whenPattern)
+			assert.NoError(t, err)
+
+			var shapes []string
+			var defaults []map[string]string
+			for _, v := range variants {
+				shapes = append(shapes, strings.ReplaceAll(v.shape(), "\x00", ""))
+				defaults = append(defaults, v.defaults)
+			}
+			assert.Equal(t, tc.expectShapes, shapes)
+			assert.Equal(t, tc.expectDefaults, defaults)
+		})
+	}
+}
+
+func TestBuildPath(t *testing.T) {
+	testCases := []struct {
+		whenPattern string
+		whenParam   map[string]string
+		expectPath  string
+		expectErr   error
+	}{
+		{
+			whenPattern: "/static",
+			whenParam:   nil,
+			expectPath:  "/static",
+		},
+		{
+			whenPattern: "/params/:foo/bar/:qux/*",
+			whenParam:   map[string]string{"foo": "1", "qux": "a b", "*": "c/d?"},
+			expectPath:  "/params/1/bar/a%20b/c/d%3F",
+		},
+		{
+			whenPattern: "/params/\\:foo/:bar",
+			whenParam:   map[string]string{"bar": "x/y"},
+			expectPath:  "/params/:foo/x%2Fy",
+		},
+		{
+			whenPattern: "/repos/*path/blob/:ref",
+			whenParam:   map[string]string{"path": "a/b", "ref": "main"},
+			expectPath:  "/repos/a/b/blob/main",
+		},
+		{
+			whenPattern: "/users/:id<[0-9]+>",
+			whenParam:   map[string]string{"id": "12"},
+			expectPath:  "/users/12",
+		},
+		{
+			whenPattern: "/list(/:page=1(/:size))",
+			whenParam:   map[string]string{},
+			expectPath:  "/list",
+		},
+		{
+			whenPattern: "/list(/:page=1(/:size))",
+			whenParam:   map[string]string{"page": "2"},
+			expectPath:  "/list/2",
+		},
+		{
+			whenPattern: "/list(/:page=1(/:size))",
+			whenParam:   map[string]string{"page": "2", "size": "10"},
+			expectPath:  "/list/2/10",
+		},
+		{
+			whenPattern: "/list(/:page=1(/:size))",
+			whenParam:   map[string]string{"size": "10"},
+			expectPath:  "/list/1/10",
+		},
+		{
+			whenPattern: "/list(/:page(/:size))",
+			whenParam:   map[string]string{"size": "10"},
+			expectErr:   ErrMissingParam,
+		},
+		{
+			whenPattern: "/list(/:page<[0-9]+>=first(/:size))",
+			whenParam:   map[string]string{"size": "10"},
+			expectErr:   ErrInvalidParam,
+		},
+		{
+			whenPattern: "/users/:id<[0-9]+>",
+			whenParam:   map[string]string{"id": "me"},
+			expectErr:   ErrInvalidParam,
+		},
+		{
+			whenPattern: "/params/:foo/bar/:qux",
+			whenParam:   map[string]string{"foo": "1"},
+			expectErr:   ErrMissingParam,
+		},
+		{
+			whenPattern: "/params/:foo",
+			whenParam:   map[string]string{"foo": "1", "qux": "2"},
+			expectErr:   ErrUnknownParam,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.whenPattern, func(t *testing.T) {
+			path, err := BuildPath(tc.whenPattern, tc.whenParam)
+			if tc.expectErr != nil {
+				assert.ErrorIs(t, err, tc.expectErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectPath, path)
+		})
+	}
+}
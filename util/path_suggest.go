@@ -0,0 +1,221 @@
+package util
+
+import (
+	"golang.org/x/exp/constraints"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+type (
+	suggestion struct {
+		pattern  string
+		distance float64
+		score    float64
+	}
+
+	suggestSegment struct {
+		static string
+		match  *regexp.Regexp
+		any    bool
+	}
+)
+
+// Suggest lists up to n routes close to path, closest first. n <= 0 lists every route close enough.
+func (m *PathMatcher[T]) Suggest(path string, n int) []string {
+	path = m.options.static(m.options.normalize(path))
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+
+	var suggestions []suggestion
+	for _, pattern := range m.Routes() {
+		variants, err := parsePattern(pattern)
+		if err != nil {
+			continue
+		}
+
+		var best *suggestion
+		for _, v := range variants {
+			candidate := suggestSegments(v, m.options.static)
+
+			// Prefer patterns sharing leading segments and having as many segments as the path.
+			distance := segmentDistance(segments, candidate)
+			prefix := sharedPrefix(segments, candidate)
+			shape := maxOf(len(segments)-len(candidate), len(candidate)-len(segments))
+
+			s := suggestion{
+				pattern:  pattern,
+				distance: distance,
+				score:    distance - 0.5*float64(prefix) + 0.1*float64(shape),
+			}
+			if best == nil || s.score < best.score {
+				best = &s
+			}
+		}
+
+		// Too far from every part of the path to be a typo.
+		if best != nil && best.distance <= float64(len(segments)+1)/3 {
+			suggestions = append(suggestions, *best)
+		}
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i].score < suggestions[j].score
+	})
+
+	var res []string
+	for _, s := range suggestions {
+		if n > 0 && len(res) == n {
+			break
+		}
+		res = append(res, s.pattern)
+	}
+	return res
+}
+
+func suggestSegments(v pathVariant, static func(string) string) []suggestSegment {
+	var segments []suggestSegment
+	var current []pathToken
+
+	flush := func() {
+		segment := suggestSegment{}
+		var expr strings.Builder
+		for _, t := range current {
+			switch t.kind {
+			case staticKind:
+				segment.static += t.text
+				expr.WriteString(regexp.QuoteMeta(t.text))
+			case paramKind:
+				if t.constraint != nil {
+					expr.WriteString(strings.TrimSuffix(strings.TrimPrefix(t.constraint.String(), "^"), "$"))
+				} else {
+					expr.WriteString("[^/]*")
+				}
+			case anyKind:
+				segment.any = true
+			}
+		}
+		if len(current) > 1 || (len(current) == 1 && current[0].kind == paramKind) {
+			segment.match = regexp.MustCompile("^" + expr.String() + "$")
+		}
+		segments = append(segments, segment)
+		current = nil
+	}
+
+	for _, t := range v.tokens {
+		if t.kind != staticKind {
+			current = append(current, t)
+			continue
+		}
+		parts := strings.Split(static(t.text), "/")
+		for i, part := range parts {
+			if i > 0 {
+				flush()
+			}
+			if part != "" {
+				current = append(current, pathToken{kind: staticKind, text: part})
+			}
+		}
+	}
+	flush()
+
+	// Patterns start with a slash, which leaves an empty first segment.
+	if len(segments) > 1 && segments[0] == (suggestSegment{}) {
+		segments = segments[1:]
+	}
+	return segments
+}
+
+// segmentDistance is the edit distance between path segments and pattern segments, where replacing a segment costs
+// as much as the share of its characters that differ, and a wildcard takes one segment for free.
+func segmentDistance(segments []string, candidate []suggestSegment) float64 {
+	prev := make([]float64, len(segments)+1)
+	curr := make([]float64, len(segments)+1)
+	for j := range prev {
+		prev[j] = float64(j)
+	}
+
+	for _, c := range candidate {
+		curr[0] = prev[0] + 1
+		for j, segment := range segments {
+			curr[j+1] = minOf(prev[j+1]+1, curr[j]+1, prev[j]+c.cost(segment))
+			if c.any {
+				curr[j+1] = minOf(curr[j+1], prev[j+1], prev[j], curr[j]+0.5)
+			}
+		}
+		if c.any {
+			curr[0] = prev[0]
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(segments)]
+}
+
+func (s suggestSegment) cost(segment string) float64 {
+	switch {
+	case s.any:
+		return 0
+	case s.match != nil && s.match.MatchString(segment):
+		return 0
+	case s.match != nil:
+		return 0.5
+	}
+
+	if s.static == segment {
+		return 0
+	}
+	// Segments differing in more than half of their characters are not a typo of each other.
+	ratio := float64(editDistance(s.static, segment)) / float64(maxOf(len(s.static), len(segment)))
+	if ratio > 0.5 {
+		return 1
+	}
+	return ratio
+}
+
+func sharedPrefix(segments []string, candidate []suggestSegment) int {
+	i := 0
+	for ; i < len(segments) && i < len(candidate); i++ {
+		if candidate[i].match != nil || candidate[i].any || candidate[i].static != segments[i] {
+			break
+		}
+	}
+	return i
+}
+
+func editDistance(a string, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = minOf(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+func minOf[T constraints.Ordered](x T, others ...T) T {
+	for _, y := range others {
+		if y < x {
+			x = y
+		}
+	}
+	return x
+}
+
+func maxOf[T constraints.Ordered](x T, others ...T) T {
+	for _, y := range others {
+		if y > x {
+			x = y
+		}
+	}
+	return x
+}
@@ -0,0 +1,65 @@
+package util
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPathMatcher_Suggest(t *testing.T) {
+	m := NewPathMatcher[int]()
+
+	m.Add("/users", 1)
+	m.Add("/users/:id<[0-9]+>", 2)
+	m.Add("/users/:id<[0-9]+>/posts", 3)
+	m.Add("/posts/:id", 4)
+	m.Add("/files/*", 5)
+	m.Add("/health", 6)
+
+	testCases := []struct {
+		whenPath         string
+		whenN            int
+		expectSuggestion []string
+	}{
+		{
+			whenPath:         "/usrs",
+			whenN:            1,
+			expectSuggestion: []string{"/users"},
+		},
+		{
+			whenPath:         "/users/1/post",
+			whenN:            2,
+			expectSuggestion: []string{"/users/:id<[0-9]+>/posts", "/users/:id<[0-9]+>"},
+		},
+		{
+			whenPath:         "/users/me",
+			whenN:            2,
+			expectSuggestion: []string{"/users/:id<[0-9]+>", "/users"},
+		},
+		{
+			whenPath:         "/file/a/b/c",
+			whenN:            1,
+			expectSuggestion: []string{"/files/*"},
+		},
+		{
+			whenPath:         "/healthz",
+			whenN:            0,
+			expectSuggestion: []string{"/health"},
+		},
+		{
+			whenPath:         "/users/me",
+			whenN:            -1,
+			expectSuggestion: []string{"/users/:id<[0-9]+>", "/users", "/posts/:id", "/files/*"},
+		},
+		{
+			whenPath:         "/something/else/entirely",
+			whenN:            3,
+			expectSuggestion: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.whenPath, func(t *testing.T) {
+			assert.Equal(t, tc.expectSuggestion, m.Suggest(tc.whenPath, tc.whenN))
+		})
+	}
+}
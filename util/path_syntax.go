@@ -0,0 +1,279 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type (
+	PathSyntax uint8
+)
+
+const (
+	SyntaxOpenAPI PathSyntax = iota
+	SyntaxGorilla
+	SyntaxChi
+	SyntaxExpress
+)
+
+var (
+	ErrUnsupportedSyntax = errors.New("unsupported syntax")
+)
+
+func ImportPattern(syntax PathSyntax, path string) (string, error) {
+	var sb strings.Builder
+
+	for i := 0; i < len(path); {
+		c := path[i]
+		switch {
+		case c == '{' && syntax != SyntaxExpress:
+			end := scanEnclosed(path, i, '{', '}')
+			if end < 0 {
+				return "", fmt.Errorf("%w: %q has an unterminated param at %d", ErrInvalidPattern, path, i)
+			}
+
+			name, constraint := path[i+1:end], ""
+			if j := strings.IndexByte(name, ':'); j >= 0 {
+				if syntax == SyntaxOpenAPI {
+					return "", fmt.Errorf("%w: %q has a regular expression, which %s does not support", ErrUnsupportedSyntax, path, syntax)
+				}
+				name, constraint = name[:j], name[j+1:]
+			}
+			i = end + 1
+
+			if err := writeImportedParam(&sb, path, name, constraint, i); err != nil {
+				return "", err
+			}
+		case c == ':' && syntax == SyntaxExpress:
+			j := i + 1
+			for ; j < len(path) && isParamNameChar(path[j]); j++ {
+			}
+			name, constraint := path[i+1:j], ""
+			if j < len(path) && path[j] == '(' {
+				end := scanEnclosed(path, j, '(', ')')
+				if end < 0 {
+					return "", fmt.Errorf("%w: %q has an unterminated regular expression at %d", ErrInvalidPattern, path, j)
+				}
+				constraint = path[j+1 : end]
+				j = end + 1
+			}
+			optional := j < len(path) && path[j] == '?'
+			if optional {
+				j++
+			}
+			i = j
+
+			if err := writeImportedParam(&sb, path, name, constraint, i); err != nil {
+				return "", err
+			}
+			if optional {
+				sb.WriteByte('?')
+			}
+		case c == '*' && (syntax == SyntaxChi || syntax == SyntaxExpress):
+			if syntax == SyntaxChi && i != len(path)-1 {
+				return "", fmt.Errorf("%w: %q has a wildcard before the end, which %s does not support", ErrUnsupportedSyntax, path, syntax)
+			}
+			sb.WriteByte(anyLabel)
+			i++
+		case c == '(' && syntax == SyntaxExpress:
+			return "", fmt.Errorf("%w: %q has an unnamed group at %d", ErrUnsupportedSyntax, path, i)
+		default:
+			if isPatternLabel(c) {
+				sb.WriteByte('\\')
+			}
+			sb.WriteByte(c)
+			i++
+		}
+	}
+
+	pattern := sb.String()
+	if _, err := parsePattern(pattern); err != nil {
+		return "", err
+	}
+	return pattern, nil
+}
+
+func ExportPattern(syntax PathSyntax, pattern string) (string, error) {
+	if _, err := parsePattern(pattern); err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	p := &patternParser{pattern: pattern}
+
+	for p.pos < len(pattern) {
+		c := pattern[p.pos]
+		switch c {
+		case '\\':
+			if p.pos+1 < len(pattern) && isPatternLabel(pattern[p.pos+1]) {
+				p.pos++
+			}
+			if err := writeExportedStatic(&sb, syntax, pattern, pattern[p.pos]); err != nil {
+				return "", err
+			}
+			p.pos++
+		case paramLabel:
+			token, _ := p.parseParam()
+			optional := p.pos < len(pattern) && pattern[p.pos] == '?'
+			if optional {
+				p.pos++
+			}
+			if p.pos < len(pattern) && pattern[p.pos] == '=' {
+				return "", fmt.Errorf("%w: %q has a default value, which %s does not support", ErrUnsupportedSyntax, pattern, syntax)
+			}
+			if optional && syntax != SyntaxExpress {
+				return "", fmt.Errorf("%w: %q has an optional param, which %s does not support", ErrUnsupportedSyntax, pattern, syntax)
+			}
+
+			if err := writeExportedParam(&sb, syntax, pattern, token); err != nil {
+				return "", err
+			}
+			if optional {
+				sb.WriteByte('?')
+			}
+		case anyLabel:
+			p.pos++
+			name := p.parseName()
+
+			switch {
+			case syntax == SyntaxOpenAPI:
+				return "", fmt.Errorf("%w: %q has a wildcard, which %s does not support", ErrUnsupportedSyntax, pattern, syntax)
+			case syntax == SyntaxGorilla && name == "":
+				return "", fmt.Errorf("%w: %q has an unnamed wildcard, which %s does not support", ErrUnsupportedSyntax, pattern, syntax)
+			case syntax == SyntaxGorilla:
+				sb.WriteString("{" + name + ":.*}")
+			case name != "":
+				return "", fmt.Errorf("%w: %q has a named wildcard, which %s does not support", ErrUnsupportedSyntax, pattern, syntax)
+			case syntax == SyntaxChi && p.pos != len(pattern):
+				return "", fmt.Errorf("%w: %q has a wildcard before the end, which %s does not support", ErrUnsupportedSyntax, pattern, syntax)
+			default:
+				sb.WriteByte(anyLabel)
+			}
+		case '(':
+			// Only "(/:name)" has a counterpart, the optional param of express.
+			end := scanEnclosed(pattern, p.pos, '(', ')')
+			group := &patternParser{pattern: pattern[p.pos+1 : end], pos: 1}
+			if syntax != SyntaxExpress || !strings.HasPrefix(group.pattern, "/:") {
+				return "", fmt.Errorf("%w: %q has an optional group, which %s does not support", ErrUnsupportedSyntax, pattern, syntax)
+			}
+			token, err := group.parseParam()
+			if err != nil || group.pos != len(group.pattern) {
+				return "", fmt.Errorf("%w: %q has an optional group, which %s does not support", ErrUnsupportedSyntax, pattern, syntax)
+			}
+
+			sb.WriteByte('/')
+			if err := writeExportedParam(&sb, syntax, pattern, token); err != nil {
+				return "", err
+			}
+			sb.WriteByte('?')
+			p.pos = end + 1
+		default:
+			if err := writeExportedStatic(&sb, syntax, pattern, c); err != nil {
+				return "", err
+			}
+			p.pos++
+		}
+	}
+
+	return sb.String(), nil
+}
+
+func (s PathSyntax) String() string {
+	switch s {
+	case SyntaxOpenAPI:
+		return "OpenAPI"
+	case SyntaxGorilla:
+		return "gorilla"
+	case SyntaxChi:
+		return "chi"
+	case SyntaxExpress:
+		return "express"
+	default:
+		return fmt.Sprintf("PathSyntax(%d)", uint8(s))
+	}
+}
+
+func writeImportedParam(sb *strings.Builder, path string, name string, constraint string, next int) error {
+	if name == "" {
+		return fmt.Errorf("%w: %q has an unnamed param", ErrInvalidPattern, path)
+	}
+	for i := 0; i < len(name); i++ {
+		if !isParamNameChar(name[i]) {
+			return fmt.Errorf("%w: %q has the param name %q, only letters, digits and underscores are supported", ErrUnsupportedSyntax, path, name)
+		}
+	}
+	// Nothing ends a param name but the first character that cannot be part of it.
+	if next < len(path) && (isParamNameChar(path[next]) || (constraint == "" && path[next] == '<')) {
+		return fmt.Errorf("%w: %q has the param %q directly followed by %q", ErrUnsupportedSyntax, path, name, path[next])
+	}
+
+	sb.WriteByte(paramLabel)
+	sb.WriteString(name)
+	if constraint != "" {
+		sb.WriteString("<" + constraint + ">")
+	}
+	return nil
+}
+
+func writeExportedParam(sb *strings.Builder, syntax PathSyntax, pattern string, token pathToken) error {
+	constraint := ""
+	if token.constraint != nil {
+		constraint = strings.TrimSuffix(strings.TrimPrefix(token.constraint.String(), "^(?:"), ")$")
+	}
+
+	switch syntax {
+	case SyntaxOpenAPI:
+		if constraint != "" {
+			return fmt.Errorf("%w: %q has a constraint, which %s does not support", ErrUnsupportedSyntax, pattern, syntax)
+		}
+		sb.WriteString("{" + token.name + "}")
+	case SyntaxExpress:
+		sb.WriteString(string(paramLabel) + token.name)
+		if constraint != "" {
+			sb.WriteString("(" + constraint + ")")
+		}
+	default:
+		if constraint != "" {
+			sb.WriteString("{" + token.name + ":" + constraint + "}")
+		} else {
+			sb.WriteString("{" + token.name + "}")
+		}
+	}
+	return nil
+}
+
+func writeExportedStatic(sb *strings.Builder, syntax PathSyntax, pattern string, c byte) error {
+	special := "{}"
+	switch syntax {
+	case SyntaxChi:
+		special = "{}*"
+	case SyntaxExpress:
+		special = ":*()?"
+	}
+	if strings.IndexByte(special, c) >= 0 {
+		return fmt.Errorf("%w: %q has the literal %q, which %s cannot express", ErrUnsupportedSyntax, pattern, c, syntax)
+	}
+
+	sb.WriteByte(c)
+	return nil
+}
+
+// scanEnclosed returns the index of the close matching the open at start, or -1.
+func scanEnclosed(s string, start int, open byte, close byte) int {
+	depth := 0
+	for i := start; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case open:
+			depth++
+		case close:
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
@@ -0,0 +1,170 @@
+package util
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestImportPattern(t *testing.T) {
+	testCases := []struct {
+		whenSyntax    PathSyntax
+		whenPath      string
+		expectPattern string
+		expectErr     error
+	}{
+		{
+			whenSyntax:    SyntaxOpenAPI,
+			whenPath:      "/users/{id}/files/{name}.{ext}",
+			expectPattern: "/users/:id/files/:name.:ext",
+		},
+		{
+			whenSyntax:    SyntaxOpenAPI,
+			whenPath:      "/items:batch(1)",
+			expectPattern: "/items\\:batch\\(1\\)",
+		},
+		{
+			whenSyntax: SyntaxOpenAPI,
+			whenPath:   "/users/{user-id}",
+			expectErr:  ErrUnsupportedSyntax,
+		},
+		{
+			whenSyntax: SyntaxOpenAPI,
+			whenPath:   "/users/{id:[0-9]+}",
+			expectErr:  ErrUnsupportedSyntax,
+		},
+		{
+			whenSyntax: SyntaxOpenAPI,
+			whenPath:   "/users/{id}x",
+			expectErr:  ErrUnsupportedSyntax,
+		},
+		{
+			whenSyntax: SyntaxOpenAPI,
+			whenPath:   "/users/{id",
+			expectErr:  ErrInvalidPattern,
+		},
+		{
+			whenSyntax:    SyntaxGorilla,
+			whenPath:      "/articles/{category}/{id:[0-9]{1,3}}",
+			expectPattern: "/articles/:category/:id<[0-9]{1,3}>",
+		},
+		{
+			whenSyntax:    SyntaxChi,
+			whenPath:      "/files/{bucket:[a-z]+}/*",
+			expectPattern: "/files/:bucket<[a-z]+>/*",
+		},
+		{
+			whenSyntax: SyntaxChi,
+			whenPath:   "/files/*/meta",
+			expectErr:  ErrUnsupportedSyntax,
+		},
+		{
+			whenSyntax:    SyntaxExpress,
+			whenPath:      "/users/:id(\\d+)/:tab?",
+			expectPattern: "/users/:id<\\d+>/:tab?",
+		},
+		{
+			whenSyntax:    SyntaxExpress,
+			whenPath:      "/static/*",
+			expectPattern: "/static/*",
+		},
+		{
+			whenSyntax: SyntaxExpress,
+			whenPath:   "/(a|b)",
+			expectErr:  ErrUnsupportedSyntax,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.whenSyntax.String()+" "+tc.whenPath, func(t *testing.T) {
+			pattern, err := ImportPattern(tc.whenSyntax, tc.whenPath)
+			if tc.expectErr != nil {
+				assert.ErrorIs(t, err, tc.expectErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectPattern, pattern)
+		})
+	}
+}
+
+func TestExportPattern(t *testing.T) {
+	testCases := []struct {
+		whenSyntax  PathSyntax
+		whenPattern string
+		expectPath  string
+		expectErr   error
+	}{
+		{
+			whenSyntax:  SyntaxOpenAPI,
+			whenPattern: "/users/:id/files/:name.:ext",
+			expectPath:  "/users/{id}/files/{name}.{ext}",
+		},
+		{
+			whenSyntax:  SyntaxOpenAPI,
+			whenPattern: "/items\\:batch",
+			expectPath:  "/items:batch",
+		},
+		{
+			whenSyntax:  SyntaxOpenAPI,
+			whenPattern: "/users/:id<[0-9]+>",
+			expectErr:   ErrUnsupportedSyntax,
+		},
+		{
+			whenSyntax:  SyntaxOpenAPI,
+			whenPattern: "/files/*",
+			expectErr:   ErrUnsupportedSyntax,
+		},
+		{
+			whenSyntax:  SyntaxGorilla,
+			whenPattern: "/users/:id<[0-9]+>/*path",
+			expectPath:  "/users/{id:[0-9]+}/{path:.*}",
+		},
+		{
+			whenSyntax:  SyntaxGorilla,
+			whenPattern: "/users(/:id)",
+			expectErr:   ErrUnsupportedSyntax,
+		},
+		{
+			whenSyntax:  SyntaxChi,
+			whenPattern: "/files/:bucket/*",
+			expectPath:  "/files/{bucket}/*",
+		},
+		{
+			whenSyntax:  SyntaxChi,
+			whenPattern: "/files/*path",
+			expectErr:   ErrUnsupportedSyntax,
+		},
+		{
+			whenSyntax:  SyntaxExpress,
+			whenPattern: "/users/:id<\\d+>(/:tab)/:page?",
+			expectPath:  "/users/:id(\\d+)/:tab?/:page?",
+		},
+		{
+			whenSyntax:  SyntaxExpress,
+			whenPattern: "/list(/:page=1)",
+			expectErr:   ErrUnsupportedSyntax,
+		},
+		{
+			whenSyntax:  SyntaxExpress,
+			whenPattern: "/users(/:id/posts)",
+			expectErr:   ErrUnsupportedSyntax,
+		},
+		{
+			whenSyntax:  SyntaxExpress,
+			whenPattern: "/users(",
+			expectErr:   ErrInvalidPattern,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.whenSyntax.String()+" "+tc.whenPattern, func(t *testing.T) {
+			path, err := ExportPattern(tc.whenSyntax, tc.whenPattern)
+			if tc.expectErr != nil {
+				assert.ErrorIs(t, err, tc.expectErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectPath, path)
+		})
+	}
+}
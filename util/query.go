@@ -0,0 +1,510 @@
+package util
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"github.com/iancoleman/strcase"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type (
+	QueryOption func(o *queryOptions)
+
+	ArrayStyle uint8
+
+	queryOptions struct {
+		arrayStyle ArrayStyle
+	}
+
+	queryEntry struct {
+		key    string
+		path   []string
+		values []string
+	}
+)
+
+const (
+	// ArrayStyleIndices writes a[0]=x&a[1]=y.
+	ArrayStyleIndices ArrayStyle = iota
+	// ArrayStyleBrackets writes a[]=x&a[]=y.
+	ArrayStyleBrackets
+	// ArrayStyleRepeat writes a=x&a=y.
+	ArrayStyleRepeat
+	// ArrayStyleComma writes a=x,y.
+	ArrayStyleComma
+)
+
+// queryIndexLimit keeps a query like a[999999999]=x from allocating a huge slice.
+const queryIndexLimit = 1000
+
+// queryDepthLimit stops encoding values that point back at themselves, like a node of a cyclic list.
+const queryDepthLimit = 32
+
+var ErrInvalidQuery = errors.New("invalid query")
+
+var (
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	stringerType        = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+)
+
+func WithArrayStyle(style ArrayStyle) QueryOption {
+	return func(o *queryOptions) {
+		o.arrayStyle = style
+	}
+}
+
+func newQueryOptions(options []QueryOption) queryOptions {
+	var o queryOptions
+	for _, option := range options {
+		option(&o)
+	}
+	return o
+}
+
+func EncodeQuery(v any, options ...QueryOption) url.Values {
+	o := newQueryOptions(options)
+
+	values := url.Values{}
+	encodeQuery(values, "", reflect.ValueOf(v), &o, 0)
+	return values
+}
+
+func DecodeQuery(values url.Values, target any, options ...QueryOption) error {
+	o := newQueryOptions(options)
+
+	dst := reflect.ValueOf(target)
+	if dst.Kind() == reflect.Pointer && !dst.IsNil() {
+		dst = dst.Elem()
+	} else if dst.Kind() != reflect.Map || dst.IsNil() {
+		return fmt.Errorf("%w: target must be a non-nil pointer or map, not %T", ErrInvalidQuery, target)
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	// Keys naming the same path, like a[]=x&a=y, are decoded together. A path can not hold a value and nested keys at
+	// once, a=1&a[b]=2 is an error instead of one of them winning.
+	var entries []queryEntry
+	seen := map[string]int{}
+	for _, key := range keys {
+		path := parseKey(queryKey(key))
+		name := strings.Join(path, ".")
+		if i, ok := seen[name]; ok {
+			entries[i].values = append(entries[i].values, values[key]...)
+			continue
+		}
+		seen[name] = len(entries)
+		entries = append(entries, queryEntry{key: key, path: path, values: append([]string(nil), values[key]...)})
+	}
+	for _, entry := range entries {
+		for i := 1; i < len(entry.path); i++ {
+			if j, ok := seen[strings.Join(entry.path[:i], ".")]; ok {
+				return fmt.Errorf("%w: %q and %q both set %q", ErrInvalidQuery, entries[j].key, entry.key, entries[j].key)
+			}
+		}
+	}
+
+	for _, entry := range entries {
+		if err := decodeQuery(dst, entry.path, entry.values, entry.key, &o); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// queryKey turns the bracket notation of a query key into the key syntax of Get and Set, filter[user][name] into
+// filter.user.name and sort[0] into sort[0]. Empty brackets are dropped, a[] means every value of a.
+func queryKey(key string) string {
+	var sb strings.Builder
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if c != '[' {
+			sb.WriteByte(c)
+			continue
+		}
+		end := strings.IndexByte(key[i:], ']')
+		if end < 0 {
+			sb.WriteString(key[i:])
+			break
+		}
+		end += i
+
+		if name := key[i+1 : end]; name != "" {
+			if _, err := strconv.Atoi(name); err == nil {
+				sb.WriteString(key[i : end+1])
+			} else {
+				sb.WriteByte('.')
+				sb.WriteString(name)
+			}
+		}
+		i = end
+	}
+	return sb.String()
+}
+
+func encodeQuery(values url.Values, key string, v reflect.Value, o *queryOptions, depth int) {
+	if depth > queryDepthLimit {
+		return
+	}
+	if text, ok := queryText(v); ok {
+		if key != "" {
+			values.Add(key, text)
+		}
+		return
+	}
+
+	// Getters are called on the pointer when there is one, it has the methods of the value as well.
+	var source reflect.Value
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		if v.Kind() == reflect.Pointer {
+			source = v
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return
+	}
+	if !source.IsValid() {
+		source = v
+	}
+
+	if rangeQueryEntries(source, func(name string, value reflect.Value) {
+		encodeQuery(values, queryChildKey(key, name), value, o, depth+1)
+	}) {
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		// Only the exported fields are keys, read like Get would, so methods that do not answer to a field are never called.
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name := strcase.ToLowerCamel(field.Name)
+			value, ok := getByMethod(source, name)
+			if !ok {
+				value = v.Field(i)
+			}
+			encodeQuery(values, queryChildKey(key, name), value, o, depth+1)
+		}
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		for _, k := range keys {
+			encodeQuery(values, queryChildKey(key, fmt.Sprint(k.Interface())), v.MapIndex(k), o, depth+1)
+		}
+	case reflect.Slice, reflect.Array:
+		if key == "" {
+			return
+		}
+
+		// Only indices can tell apart the fields of nested values, other styles are kept for lists of scalars.
+		var texts []string
+		if o.arrayStyle != ArrayStyleIndices {
+			for i := 0; i < v.Len(); i++ {
+				text, ok := queryText(v.Index(i))
+				if !ok {
+					texts = nil
+					break
+				}
+				texts = append(texts, text)
+			}
+		}
+		if texts == nil {
+			for i := 0; i < v.Len(); i++ {
+				encodeQuery(values, key+"["+strconv.Itoa(i)+"]", v.Index(i), o, depth+1)
+			}
+			return
+		}
+
+		switch o.arrayStyle {
+		case ArrayStyleBrackets:
+			values[key+"[]"] = append(values[key+"[]"], texts...)
+		case ArrayStyleRepeat:
+			values[key] = append(values[key], texts...)
+		case ArrayStyleComma:
+			values.Add(key, strings.Join(texts, ","))
+		}
+	}
+}
+
+// rangeQueryEntries lists the entries of map like values with a Range(func(key, value any) bool) method, as sync.Map.
+func rangeQueryEntries(v reflect.Value, fn func(name string, value reflect.Value)) bool {
+	method := v.MethodByName("Range")
+	if !method.IsValid() || method.Type() != reflect.TypeOf(func(func(key, value any) bool) {}) {
+		return false
+	}
+
+	entries := map[string]any{}
+	method.Call([]reflect.Value{reflect.ValueOf(func(key, value any) bool {
+		entries[fmt.Sprint(key)] = value
+		return true
+	})})
+
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fn(name, reflect.ValueOf(entries[name]))
+	}
+	return true
+}
+
+func queryChildKey(key string, name string) string {
+	if key == "" {
+		return name
+	}
+	return key + "[" + name + "]"
+}
+
+func queryText(v reflect.Value) (string, bool) {
+	if !v.IsValid() {
+		return "", false
+	}
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return "", false
+		}
+		if v.Type().Implements(textMarshalerType) || v.Type().Implements(stringerType) {
+			break
+		}
+		v = v.Elem()
+	}
+
+	if v.Type().Implements(textMarshalerType) {
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), err == nil
+	}
+	if v.Type().Implements(stringerType) {
+		return v.Interface().(fmt.Stringer).String(), true
+	}
+
+	switch v.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return fmt.Sprint(v.Interface()), true
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return string(v.Bytes()), true
+		}
+	}
+	return "", false
+}
+
+func decodeQuery(dst reflect.Value, path []string, values []string, key string, o *queryOptions) error {
+	if len(path) == 0 && dst.CanAddr() && dst.Addr().Type().Implements(textUnmarshalerType) {
+		return decodeQueryText(dst, values[len(values)-1], key)
+	}
+
+	switch dst.Kind() {
+	case reflect.Pointer:
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		return decodeQuery(dst.Elem(), path, values, key, o)
+	case reflect.Interface:
+		if dst.NumMethod() > 0 {
+			return fmt.Errorf("%w: %q can not be decoded into %s", ErrInvalidQuery, key, dst.Type())
+		}
+		if len(path) == 0 {
+			if o.arrayStyle == ArrayStyleComma {
+				values = splitQueryValues(values)
+			}
+			if len(values) == 1 {
+				dst.Set(reflect.ValueOf(values[0]))
+			} else {
+				dst.Set(reflect.ValueOf(append([]string(nil), values...)))
+			}
+			return nil
+		}
+
+		// Values held by an interface can not be set in place, decode into a copy and put it back.
+		var current reflect.Value
+		if elem := dst.Elem(); elem.IsValid() && (elem.Kind() == reflect.Map || elem.Kind() == reflect.Slice) {
+			current = reflect.New(elem.Type()).Elem()
+			current.Set(elem)
+		} else if _, err := strconv.Atoi(path[0]); err == nil {
+			current = reflect.New(reflect.TypeOf([]any(nil))).Elem()
+		} else {
+			current = reflect.New(reflect.TypeOf(map[string]any(nil))).Elem()
+		}
+		if err := decodeQuery(current, path, values, key, o); err != nil {
+			return err
+		}
+		dst.Set(current)
+		return nil
+	case reflect.Map:
+		if len(path) == 0 {
+			return fmt.Errorf("%w: %q needs a key to be decoded into %s", ErrInvalidQuery, key, dst.Type())
+		}
+		if dst.IsNil() {
+			dst.Set(reflect.MakeMap(dst.Type()))
+		}
+
+		k := reflect.New(dst.Type().Key()).Elem()
+		if err := decodeQuery(k, nil, []string{path[0]}, key, o); err != nil {
+			return err
+		}
+		elem := reflect.New(dst.Type().Elem()).Elem()
+		if current := dst.MapIndex(k); current.IsValid() {
+			elem.Set(current)
+		}
+		if err := decodeQuery(elem, path[1:], values, key, o); err != nil {
+			return err
+		}
+		dst.SetMapIndex(k, elem)
+		return nil
+	case reflect.Struct:
+		if len(path) == 0 {
+			return fmt.Errorf("%w: %q needs a field to be decoded into %s", ErrInvalidQuery, key, dst.Type())
+		}
+		// Like Set, setters come first, then the fields.
+		source := dst
+		if dst.CanAddr() {
+			source = dst.Addr()
+		}
+		if ok, err := decodeQuerySetter(source, path, values, key, o); ok {
+			return err
+		}
+		for i := 0; i < dst.NumField(); i++ {
+			field := dst.Type().Field(i)
+			if field.IsExported() && strcase.ToLowerCamel(field.Name) == path[0] {
+				return decodeQuery(dst.Field(i), path[1:], values, key, o)
+			}
+		}
+		return fmt.Errorf("%w: %q goes into %s which has no %q", ErrInvalidQuery, key, dst.Type(), path[0])
+	case reflect.Slice, reflect.Array:
+		if len(path) == 0 {
+			if dst.Kind() == reflect.Slice && dst.Type().Elem().Kind() == reflect.Uint8 {
+				dst.SetBytes([]byte(values[len(values)-1]))
+				return nil
+			}
+			if o.arrayStyle == ArrayStyleComma {
+				values = splitQueryValues(values)
+			}
+			if dst.Kind() == reflect.Slice {
+				dst.Set(reflect.MakeSlice(dst.Type(), len(values), len(values)))
+			} else if len(values) > dst.Len() {
+				return fmt.Errorf("%w: %q has %d values but %s holds %d", ErrInvalidQuery, key, len(values), dst.Type(), dst.Len())
+			}
+			for i, value := range values {
+				if err := decodeQuery(dst.Index(i), nil, []string{value}, key, o); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		index, err := strconv.Atoi(path[0])
+		if err != nil || index < 0 {
+			return fmt.Errorf("%w: %q has the index %q which is not a number", ErrInvalidQuery, key, path[0])
+		}
+		if index >= dst.Len() {
+			if dst.Kind() == reflect.Array || index >= queryIndexLimit {
+				return fmt.Errorf("%w: %q has the index %d which is out of range", ErrInvalidQuery, key, index)
+			}
+			grown := reflect.MakeSlice(dst.Type(), index+1, index+1)
+			reflect.Copy(grown, dst)
+			dst.Set(grown)
+		}
+		return decodeQuery(dst.Index(index), path[1:], values, key, o)
+	}
+
+	if len(path) > 0 {
+		return fmt.Errorf("%w: %q goes into %s which has no %q", ErrInvalidQuery, key, dst.Type(), path[0])
+	}
+	return decodeQueryText(dst, values[len(values)-1], key)
+}
+
+// decodeQuerySetter decodes into what the setter of source for path[0] takes, starting from what its getter gives.
+func decodeQuerySetter(source reflect.Value, path []string, values []string, key string, o *queryOptions) (bool, error) {
+	valueType, ok := setterType(source.Type(), path[0])
+	if !ok {
+		return false, nil
+	}
+
+	value := reflect.New(valueType).Elem()
+	if len(path) > 1 {
+		if current, ok := getByMethod(source, path[0]); ok && current.IsValid() && current.Type().AssignableTo(valueType) {
+			value.Set(current)
+		}
+	}
+	if err := decodeQuery(value, path[1:], values, key, o); err != nil {
+		return true, err
+	}
+	if !setByMethod(source, path[0], value) {
+		return true, fmt.Errorf("%w: %q could not be set on %s", ErrInvalidQuery, key, source.Type())
+	}
+	return true, nil
+}
+
+func decodeQueryText(dst reflect.Value, value string, key string) error {
+	if dst.CanAddr() {
+		if u, ok := dst.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			if err := u.UnmarshalText([]byte(value)); err != nil {
+				return fmt.Errorf("%w: %q has an invalid value %q: %s", ErrInvalidQuery, key, value, err)
+			}
+			return nil
+		}
+	}
+
+	var err error
+	switch dst.Kind() {
+	case reflect.String:
+		dst.SetString(value)
+	case reflect.Bool:
+		var b bool
+		if b, err = strconv.ParseBool(value); err == nil {
+			dst.SetBool(b)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i int64
+		if i, err = strconv.ParseInt(value, 10, dst.Type().Bits()); err == nil {
+			dst.SetInt(i)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		var u uint64
+		if u, err = strconv.ParseUint(value, 10, dst.Type().Bits()); err == nil {
+			dst.SetUint(u)
+		}
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		if f, err = strconv.ParseFloat(value, dst.Type().Bits()); err == nil {
+			dst.SetFloat(f)
+		}
+	default:
+		return fmt.Errorf("%w: %q can not be decoded into %s", ErrInvalidQuery, key, dst.Type())
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %q has an invalid value %q: %s", ErrInvalidQuery, key, value, err)
+	}
+	return nil
+}
+
+func splitQueryValues(values []string) []string {
+	var res []string
+	for _, value := range values {
+		res = append(res, strings.Split(value, ",")...)
+	}
+	return res
+}
@@ -0,0 +1,292 @@
+package util
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+type queryFilter struct {
+	User struct {
+		Name string
+		Age  int
+	}
+	Tags    []string
+	Active  *bool
+	Created time.Time
+}
+
+type queryUser struct {
+	First string
+	Last  string
+}
+
+func (u queryUser) FullName() string {
+	return u.First + " " + u.Last
+}
+
+func (u *queryUser) SetNickname(nickname string) {
+	u.First = nickname
+}
+
+type queryResource struct {
+	Name   string
+	closed *bool
+}
+
+func (r queryResource) Clone() queryResource {
+	return queryResource{Name: r.Name, closed: r.closed}
+}
+
+func (r queryResource) IsEmpty() bool {
+	return r.Name == ""
+}
+
+func (r queryResource) Close() error {
+	*r.closed = true
+	return nil
+}
+
+type querySearch struct {
+	Filter queryFilter
+	Sort   []string
+	Limit  uint
+	Score  float64
+}
+
+func TestEncodeQuery(t *testing.T) {
+	testCases := []struct {
+		whenValue   any
+		whenOptions []QueryOption
+		expectQuery url.Values
+	}{
+		{
+			whenValue: map[string]any{
+				"filter": map[string]any{"user": map[string]any{"name": "x"}},
+				"sort":   []string{"-created", "name"},
+			},
+			expectQuery: url.Values{
+				"filter[user][name]": {"x"},
+				"sort[0]":            {"-created"},
+				"sort[1]":            {"name"},
+			},
+		},
+		{
+			whenValue:   map[string]any{"sort": []string{"-created", "name"}},
+			whenOptions: []QueryOption{WithArrayStyle(ArrayStyleBrackets)},
+			expectQuery: url.Values{"sort[]": {"-created", "name"}},
+		},
+		{
+			whenValue:   map[string]any{"sort": []string{"-created", "name"}},
+			whenOptions: []QueryOption{WithArrayStyle(ArrayStyleRepeat)},
+			expectQuery: url.Values{"sort": {"-created", "name"}},
+		},
+		{
+			whenValue:   map[string]any{"sort": []string{"-created", "name"}},
+			whenOptions: []QueryOption{WithArrayStyle(ArrayStyleComma)},
+			expectQuery: url.Values{"sort": {"-created,name"}},
+		},
+		{
+			whenValue:   map[string]any{"users": []map[string]any{{"name": "x"}, {"name": "y"}}},
+			whenOptions: []QueryOption{WithArrayStyle(ArrayStyleBrackets)},
+			expectQuery: url.Values{"users[0][name]": {"x"}, "users[1][name]": {"y"}},
+		},
+		{
+			whenValue: &querySearch{
+				Filter: queryFilter{
+					Tags:    []string{"a"},
+					Created: time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC),
+				},
+				Limit: 10,
+				Score: 0.5,
+			},
+			expectQuery: url.Values{
+				"filter[user][name]": {""},
+				"filter[user][age]":  {"0"},
+				"filter[tags][0]":    {"a"},
+				"filter[created]":    {"2022-01-02T03:04:05Z"},
+				"limit":              {"10"},
+				"score":              {"0.5"},
+			},
+		},
+		{
+			whenValue: map[string]any{"user": queryUser{First: "Jo", Last: "Ann"}},
+			expectQuery: url.Values{
+				"user[first]": {"Jo"},
+				"user[last]":  {"Ann"},
+			},
+		},
+		{
+			whenValue: func() any {
+				m := &sync.Map{}
+				m.Store("filter", map[string]any{"name": "x"})
+				m.Store("limit", 10)
+				return m
+			}(),
+			expectQuery: url.Values{"filter[name]": {"x"}, "limit": {"10"}},
+		},
+		{
+			whenValue:   "x",
+			expectQuery: url.Values{},
+		},
+	}
+
+	for _, tc := range testCases {
+		res := EncodeQuery(tc.whenValue, tc.whenOptions...)
+		assert.Equal(t, tc.expectQuery, res)
+	}
+}
+
+func TestEncodeQuery_Methods(t *testing.T) {
+	closed := false
+	res := EncodeQuery(map[string]any{"item": queryResource{Name: "x", closed: &closed}})
+	assert.Equal(t, url.Values{"item[name]": {"x"}}, res)
+	assert.False(t, closed)
+}
+
+func TestDecodeQuery(t *testing.T) {
+	active := true
+
+	testCases := []struct {
+		whenQuery   string
+		whenTarget  func() any
+		whenOptions []QueryOption
+		expectValue any
+		expectErr   error
+	}{
+		{
+			whenQuery:  "filter[user][name]=x&sort[0]=-created&sort[1]=name",
+			whenTarget: func() any { return &map[string]any{} },
+			expectValue: &map[string]any{
+				"filter": map[string]any{"user": map[string]any{"name": "x"}},
+				"sort":   []any{"-created", "name"},
+			},
+		},
+		{
+			whenQuery:  "filter.user.name=x&tags[]=a&tags[]=b&ids=1&ids=2",
+			whenTarget: func() any { return map[string]any{} },
+			expectValue: map[string]any{
+				"filter": map[string]any{"user": map[string]any{"name": "x"}},
+				"tags":   []string{"a", "b"},
+				"ids":    []string{"1", "2"},
+			},
+		},
+		{
+			whenQuery:   "tags=a&tags[]=b",
+			whenTarget:  func() any { return &map[string]any{} },
+			expectValue: &map[string]any{"tags": []string{"a", "b"}},
+		},
+		{
+			whenQuery:   "sort=-created,name",
+			whenTarget:  func() any { return &map[string]any{} },
+			whenOptions: []QueryOption{WithArrayStyle(ArrayStyleComma)},
+			expectValue: &map[string]any{"sort": []string{"-created", "name"}},
+		},
+		{
+			whenQuery:  "filter[user][name]=x&filter[user][age]=30&filter[tags][]=a&filter[tags][]=b&filter[active]=true&filter[created]=2022-01-02T03:04:05Z&sort[1]=name&sort[0]=-created&limit=10&score=0.5",
+			whenTarget: func() any { return &querySearch{} },
+			expectValue: func() any {
+				v := &querySearch{Sort: []string{"-created", "name"}, Limit: 10, Score: 0.5}
+				v.Filter.User.Name = "x"
+				v.Filter.User.Age = 30
+				v.Filter.Tags = []string{"a", "b"}
+				v.Filter.Active = &active
+				v.Filter.Created = time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+				return v
+			}(),
+		},
+		{
+			whenQuery:   "sort=-created,name",
+			whenTarget:  func() any { return &querySearch{} },
+			whenOptions: []QueryOption{WithArrayStyle(ArrayStyleComma)},
+			expectValue: &querySearch{Sort: []string{"-created", "name"}},
+		},
+		{
+			whenQuery:  "unknown=1",
+			whenTarget: func() any { return &querySearch{} },
+			expectErr:  ErrInvalidQuery,
+		},
+		{
+			whenQuery:  "filter=x&filter[user][name]=y",
+			whenTarget: func() any { return &map[string]any{} },
+			expectErr:  ErrInvalidQuery,
+		},
+		{
+			whenQuery:  "filter.user=x&filter[user][name]=y",
+			whenTarget: func() any { return &map[string]any{} },
+			expectErr:  ErrInvalidQuery,
+		},
+		{
+			whenQuery:  "limit=x",
+			whenTarget: func() any { return &querySearch{} },
+			expectErr:  ErrInvalidQuery,
+		},
+		{
+			whenQuery:  "sort[x]=name",
+			whenTarget: func() any { return &querySearch{} },
+			expectErr:  ErrInvalidQuery,
+		},
+		{
+			whenQuery:  "sort[100000]=name",
+			whenTarget: func() any { return &querySearch{} },
+			expectErr:  ErrInvalidQuery,
+		},
+		{
+			whenQuery:  "limit[a]=1",
+			whenTarget: func() any { return &querySearch{} },
+			expectErr:  ErrInvalidQuery,
+		},
+		{
+			whenQuery:  "limit=1",
+			whenTarget: func() any { return querySearch{} },
+			expectErr:  ErrInvalidQuery,
+		},
+	}
+
+	for _, tc := range testCases {
+		query, err := url.ParseQuery(tc.whenQuery)
+		assert.NoError(t, err)
+
+		target := tc.whenTarget()
+		err = DecodeQuery(query, target, tc.whenOptions...)
+		if tc.expectErr != nil {
+			assert.ErrorIs(t, err, tc.expectErr, tc.whenQuery)
+			continue
+		}
+		assert.NoError(t, err, tc.whenQuery)
+		assert.Equal(t, tc.expectValue, target, tc.whenQuery)
+	}
+}
+
+func TestDecodeQuery_Setter(t *testing.T) {
+	var m sync.Map
+	err := DecodeQuery(url.Values{"filter[user][name]": {"x"}, "filter[user][age]": {"30"}, "limit": {"10"}}, &m)
+	assert.NoError(t, err)
+
+	filter, ok := Get[map[string]any](&m, "filter")
+	assert.True(t, ok)
+	assert.Equal(t, map[string]any{"user": map[string]any{"name": "x", "age": "30"}}, filter)
+	limit, ok := Get[string](&m, "limit")
+	assert.True(t, ok)
+	assert.Equal(t, "10", limit)
+
+	var user queryUser
+	err = DecodeQuery(url.Values{"nickname": {"Jo"}, "last": {"Ann"}}, &user)
+	assert.NoError(t, err)
+	assert.Equal(t, queryUser{First: "Jo", Last: "Ann"}, user)
+}
+
+func TestEncodeQuery_RoundTrip(t *testing.T) {
+	for _, style := range []ArrayStyle{ArrayStyleIndices, ArrayStyleBrackets, ArrayStyleRepeat, ArrayStyleComma} {
+		v := querySearch{Sort: []string{"-created", "name"}, Limit: 10}
+		v.Filter.User.Name = "x"
+		v.Filter.Tags = []string{"a", "b"}
+
+		var res querySearch
+		err := DecodeQuery(EncodeQuery(v, WithArrayStyle(style)), &res, WithArrayStyle(style))
+		assert.NoError(t, err)
+		assert.Equal(t, v, res)
+	}
+}
@@ -0,0 +1,128 @@
+package util
+
+import (
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+type (
+	RouteOption func(o *routeOptions)
+
+	routeOptions struct {
+		predicates []routePredicate
+	}
+
+	routePredicate struct {
+		source predicateSource
+		name   string
+		value  string
+	}
+
+	predicateSource uint8
+
+	// routeRequest gives the query and headers of a request to the predicates, it parses the query once.
+	routeRequest struct {
+		request *http.Request
+		query   url.Values
+	}
+)
+
+const (
+	querySource predicateSource = iota
+	headerSource
+)
+
+// WithQuery only matches requests having the query parameter, with the value unless it is empty.
+func WithQuery(name string, value string) RouteOption {
+	return func(o *routeOptions) {
+		o.predicates = append(o.predicates, routePredicate{source: querySource, name: name, value: value})
+	}
+}
+
+// WithHeader only matches requests having the header, with the value among its comma separated elements unless it is empty.
+func WithHeader(name string, value string) RouteOption {
+	return func(o *routeOptions) {
+		o.predicates = append(o.predicates, routePredicate{source: headerSource, name: textproto.CanonicalMIMEHeaderKey(name), value: value})
+	}
+}
+
+func newRouteOptions(options []RouteOption) routeOptions {
+	var o routeOptions
+	for _, option := range options {
+		option(&o)
+	}
+	sort.Slice(o.predicates, func(i, j int) bool {
+		return o.predicates[i].String() < o.predicates[j].String()
+	})
+	return o
+}
+
+// routeKey identifies a route by its pattern and predicates.
+func routeKey(pattern string, predicates []routePredicate) string {
+	var sb strings.Builder
+	sb.WriteString(pattern)
+	for _, p := range predicates {
+		sb.WriteString(" [")
+		sb.WriteString(p.String())
+		sb.WriteString("]")
+	}
+	return sb.String()
+}
+
+func (p routePredicate) accepts(r *routeRequest) bool {
+	if r == nil || r.request == nil {
+		return false
+	}
+
+	switch p.source {
+	case querySource:
+		if r.query == nil {
+			r.query = r.request.URL.Query()
+		}
+		values, ok := r.query[p.name]
+		if !ok {
+			return false
+		}
+		if p.value == "" {
+			return true
+		}
+		for _, v := range values {
+			if v == p.value {
+				return true
+			}
+		}
+	case headerSource:
+		values, ok := r.request.Header[p.name]
+		if !ok {
+			return false
+		}
+		if p.value == "" {
+			return true
+		}
+		for _, v := range values {
+			for _, element := range strings.Split(v, ",") {
+				if i := strings.IndexByte(element, ';'); i >= 0 {
+					element = element[:i]
+				}
+				if strings.EqualFold(strings.TrimSpace(element), p.value) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func (p routePredicate) String() string {
+	source := "query"
+	if p.source == headerSource {
+		source = "header"
+	}
+	if p.value == "" {
+		return source + " " + p.name
+	}
+	return source + " " + p.name + "=" + p.value
+}
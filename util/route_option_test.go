@@ -0,0 +1,149 @@
+package util
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPathMatcher_MatchRequest(t *testing.T) {
+	m := NewPathMatcher[string]()
+
+	m.Add("/export", "plain")
+	m.Add("/export", "csv", WithQuery("format", "csv"))
+	m.Add("/export", "json csv", WithQuery("format", "csv"), WithHeader("Accept", "application/json"))
+	m.Add("/users/me", "admin", WithHeader("X-Admin", ""))
+	m.Add("/users/:id", "user")
+
+	testCases := []struct {
+		whenPath      string
+		whenHeader    http.Header
+		expectValue   string
+		expectPattern string
+	}{
+		{
+			whenPath:      "/export",
+			expectValue:   "plain",
+			expectPattern: "/export",
+		},
+		{
+			whenPath:      "/export?format=csv",
+			expectValue:   "csv",
+			expectPattern: "/export",
+		},
+		{
+			whenPath:      "/export?format=csv",
+			whenHeader:    http.Header{"Accept": {"text/html, application/json;q=0.9"}},
+			expectValue:   "json csv",
+			expectPattern: "/export",
+		},
+		{
+			whenPath:      "/export?format=xml",
+			whenHeader:    http.Header{"Accept": {"application/json"}},
+			expectValue:   "plain",
+			expectPattern: "/export",
+		},
+		{
+			whenPath:      "/users/me",
+			whenHeader:    http.Header{"X-Admin": {"1"}},
+			expectValue:   "admin",
+			expectPattern: "/users/me",
+		},
+		{
+			whenPath:      "/users/me",
+			expectValue:   "user",
+			expectPattern: "/users/:id",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.whenPath, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tc.whenPath, nil)
+			for name, values := range tc.whenHeader {
+				r.Header[name] = values
+			}
+
+			value, pattern, _ := m.MatchRequest(r)
+			assert.Equal(t, tc.expectValue, value)
+			assert.Equal(t, tc.expectPattern, pattern)
+		})
+	}
+
+	value, _, _ := m.Match("/export")
+	assert.Equal(t, "plain", value)
+}
+
+func TestPathMatcher_AddEWithOptions(t *testing.T) {
+	m := NewPathMatcher[string]()
+
+	assert.NoError(t, m.AddE("/export", "plain"))
+	assert.NoError(t, m.AddE("/export", "csv", WithQuery("format", "csv")))
+	assert.ErrorIs(t, m.AddE("/export", "csv", WithQuery("format", "csv")), ErrRouteConflict)
+
+	assert.True(t, m.Remove("/export", WithQuery("format", "csv")))
+	assert.False(t, m.Remove("/export", WithQuery("format", "csv")))
+
+	r := httptest.NewRequest(http.MethodGet, "/export?format=csv", nil)
+	value, _, _ := m.MatchRequest(r)
+	assert.Equal(t, "plain", value)
+
+	assert.True(t, m.Remove("/export"))
+	assert.Empty(t, m.Routes())
+}
+
+func TestRouter_HandleWithOptions(t *testing.T) {
+	r := NewRouter()
+
+	handler := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("X-Handler", name)
+		}
+	}
+
+	r.HandleFunc(http.MethodGet, "/export", handler("plain"))
+	r.HandleFunc(http.MethodGet, "/export", handler("csv"), WithQuery("format", "csv"))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/export?format=csv", nil))
+	assert.Equal(t, "csv", w.Header().Get("X-Handler"))
+
+	assert.True(t, r.Remove(http.MethodGet, "/export", WithQuery("format", "csv")))
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/export?format=csv", nil))
+	assert.Equal(t, "plain", w.Header().Get("X-Handler"))
+}
+
+func TestRouter_HandleWithOptions_MethodFallback(t *testing.T) {
+	r := NewRouter()
+
+	handler := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("X-Handler", name)
+		}
+	}
+
+	r.HandleFunc(http.MethodGet, "/export", handler("plain"))
+	r.HandleFunc(http.MethodPost, "/export", handler("csv"), WithQuery("format", "csv"))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/export?format=csv", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "plain", w.Header().Get("X-Handler"))
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/export?format=csv", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "csv", w.Header().Get("X-Handler"))
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/export?format=csv", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, "GET, HEAD, OPTIONS, POST", w.Header().Get("Allow"))
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/export", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, "GET, HEAD, OPTIONS", w.Header().Get("Allow"))
+}
@@ -0,0 +1,183 @@
+package util
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+type (
+	Router struct {
+		NotFound         http.Handler
+		MethodNotAllowed http.Handler
+
+		matcher *PathMatcher[methodHandlers]
+		routes  map[string]methodHandlers
+	}
+
+	methodHandlers map[string]http.Handler
+
+	routeContext struct {
+		pattern string
+		params  map[string]string
+	}
+	routeContextKey struct{}
+)
+
+func NewRouter(options ...PathMatcherOption) *Router {
+	return &Router{
+		matcher: NewPathMatcher[methodHandlers](options...),
+		routes:  map[string]methodHandlers{},
+	}
+}
+
+func PathParams(r *http.Request) map[string]string {
+	if c, ok := r.Context().Value(routeContextKey{}).(*routeContext); ok {
+		return c.params
+	}
+	return nil
+}
+
+func RoutePattern(r *http.Request) string {
+	if c, ok := r.Context().Value(routeContextKey{}).(*routeContext); ok {
+		return c.pattern
+	}
+	return ""
+}
+
+func (r *Router) Handle(method string, path string, handler http.Handler, options ...RouteOption) {
+	key := routeKey(path, newRouteOptions(options).predicates)
+	handlers, ok := r.routes[key]
+	if !ok {
+		handlers = methodHandlers{}
+		r.routes[key] = handlers
+		r.matcher.Add(path, handlers, options...)
+	}
+	handlers[strings.ToUpper(method)] = handler
+}
+
+func (r *Router) HandleFunc(method string, path string, handler http.HandlerFunc, options ...RouteOption) {
+	r.Handle(method, path, handler, options...)
+}
+
+func (r *Router) Remove(method string, path string, options ...RouteOption) bool {
+	key := routeKey(path, newRouteOptions(options).predicates)
+	handlers, ok := r.routes[key]
+	if !ok {
+		return false
+	}
+	method = strings.ToUpper(method)
+	if _, ok := handlers[method]; !ok {
+		return false
+	}
+
+	delete(handlers, method)
+	if len(handlers) == 0 {
+		delete(r.routes, key)
+		r.matcher.Remove(path, options...)
+	}
+	return true
+}
+
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	path := r.matcher.requestPath(req)
+
+	// Candidates without a handler for the method fall through to the next one, their methods make up Allow.
+	var n *route[methodHandlers]
+	var paramValues []string
+	allowed := methodHandlers{}
+	toggled := r.matcher.lookup(path, req, nil, func(candidate *route[methodHandlers], values []string) bool {
+		if _, ok := candidate.value.handler(req.Method); ok {
+			n, paramValues = candidate, values
+			return true
+		}
+		for method, handler := range candidate.value {
+			allowed[method] = handler
+		}
+		return false
+	})
+
+	var canonical string
+	if n != nil {
+		canonical = r.matcher.canonical(n, paramValues, toggled)
+	} else {
+		n, paramValues, canonical = r.matcher.matchFirst(path, req)
+	}
+	if n == nil {
+		if r.NotFound != nil {
+			r.NotFound.ServeHTTP(w, req)
+		} else {
+			http.NotFound(w, req)
+		}
+		return
+	}
+
+	if canonical != path {
+		u := *req.URL
+		u.Path = canonical
+		u.RawPath = ""
+		if r.matcher.options.escapedPath {
+			u.Path, _ = url.PathUnescape(canonical)
+			u.RawPath = canonical
+		}
+
+		code := http.StatusMovedPermanently
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			code = http.StatusPermanentRedirect
+		}
+		http.Redirect(w, req, u.String(), code)
+		return
+	}
+
+	req = req.WithContext(context.WithValue(req.Context(), routeContextKey{}, &routeContext{
+		pattern: n.pristinePath,
+		params:  n.params(paramValues, r.matcher.options.unescapeParams()),
+	}))
+
+	if handler, ok := n.value.handler(req.Method); ok {
+		handler.ServeHTTP(w, req)
+		return
+	}
+
+	w.Header().Set("Allow", allowed.allow())
+	if req.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if r.MethodNotAllowed != nil {
+		r.MethodNotAllowed.ServeHTTP(w, req)
+	} else {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+}
+
+// handler finds the handler of method, HEAD falls back to GET like in net/http.
+func (h methodHandlers) handler(method string) (http.Handler, bool) {
+	if handler, ok := h[method]; ok {
+		return handler, true
+	}
+	if method == http.MethodHead {
+		handler, ok := h[http.MethodGet]
+		return handler, ok
+	}
+	return nil, false
+}
+
+func (h methodHandlers) allow() string {
+	methods := make([]string, 0, len(h)+2)
+	for method := range h {
+		methods = append(methods, method)
+	}
+	if _, ok := h[http.MethodHead]; !ok {
+		if _, ok := h[http.MethodGet]; ok {
+			methods = append(methods, http.MethodHead)
+		}
+	}
+	if _, ok := h[http.MethodOptions]; !ok {
+		methods = append(methods, http.MethodOptions)
+	}
+	sort.Strings(methods)
+	return strings.Join(methods, ", ")
+}
@@ -0,0 +1,187 @@
+package util
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRouter_ServeHTTP(t *testing.T) {
+	r := NewRouter()
+
+	handler := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("X-Handler", name)
+			w.Header().Set("X-Pattern", RoutePattern(req))
+			w.Header().Set("X-Id", PathParams(req)["id"])
+		}
+	}
+
+	r.HandleFunc(http.MethodGet, "/users", handler("list"))
+	r.HandleFunc(http.MethodPost, "/users", handler("create"))
+	r.HandleFunc(http.MethodGet, "/users/:id", handler("get"))
+	r.HandleFunc(http.MethodDelete, "/users/:id", handler("delete"))
+	r.HandleFunc(http.MethodGet, "/status", handler("status"))
+	r.HandleFunc(http.MethodHead, "/status", handler("head"))
+
+	testCases := []struct {
+		whenMethod    string
+		whenPath      string
+		expectStatus  int
+		expectHandler string
+		expectPattern string
+		expectId      string
+		expectAllow   string
+	}{
+		{
+			whenMethod:    http.MethodGet,
+			whenPath:      "/users",
+			expectStatus:  http.StatusOK,
+			expectHandler: "list",
+			expectPattern: "/users",
+		},
+		{
+			whenMethod:    http.MethodPost,
+			whenPath:      "/users",
+			expectStatus:  http.StatusOK,
+			expectHandler: "create",
+			expectPattern: "/users",
+		},
+		{
+			whenMethod:    http.MethodDelete,
+			whenPath:      "/users/1",
+			expectStatus:  http.StatusOK,
+			expectHandler: "delete",
+			expectPattern: "/users/:id",
+			expectId:      "1",
+		},
+		{
+			whenMethod:   http.MethodPut,
+			whenPath:     "/users/1",
+			expectStatus: http.StatusMethodNotAllowed,
+			expectAllow:  "DELETE, GET, HEAD, OPTIONS",
+		},
+		{
+			whenMethod:   http.MethodOptions,
+			whenPath:     "/users",
+			expectStatus: http.StatusNoContent,
+			expectAllow:  "GET, HEAD, OPTIONS, POST",
+		},
+		{
+			whenMethod:    http.MethodHead,
+			whenPath:      "/users/1",
+			expectStatus:  http.StatusOK,
+			expectHandler: "get",
+			expectPattern: "/users/:id",
+			expectId:      "1",
+		},
+		{
+			whenMethod:    http.MethodHead,
+			whenPath:      "/status",
+			expectStatus:  http.StatusOK,
+			expectHandler: "head",
+			expectPattern: "/status",
+		},
+		{
+			whenMethod:   http.MethodPost,
+			whenPath:     "/status",
+			expectStatus: http.StatusMethodNotAllowed,
+			expectAllow:  "GET, HEAD, OPTIONS",
+		},
+		{
+			whenMethod:   http.MethodGet,
+			whenPath:     "/posts",
+			expectStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.whenMethod+" "+tc.whenPath, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(tc.whenMethod, tc.whenPath, nil))
+
+			assert.Equal(t, tc.expectStatus, w.Code)
+			assert.Equal(t, tc.expectHandler, w.Header().Get("X-Handler"))
+			assert.Equal(t, tc.expectPattern, w.Header().Get("X-Pattern"))
+			assert.Equal(t, tc.expectId, w.Header().Get("X-Id"))
+			assert.Equal(t, tc.expectAllow, w.Header().Get("Allow"))
+		})
+	}
+}
+
+func TestRouter_Remove(t *testing.T) {
+	r := NewRouter()
+
+	r.HandleFunc(http.MethodGet, "/users", func(w http.ResponseWriter, req *http.Request) {})
+	r.HandleFunc(http.MethodPost, "/users", func(w http.ResponseWriter, req *http.Request) {})
+
+	assert.True(t, r.Remove(http.MethodPost, "/users"))
+	assert.False(t, r.Remove(http.MethodPost, "/users"))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/users", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, "GET, HEAD, OPTIONS", w.Header().Get("Allow"))
+
+	assert.True(t, r.Remove(http.MethodGet, "/users"))
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestRouter_Redirect(t *testing.T) {
+	r := NewRouter(WithCollapseSlashes(), WithTrailingSlash(TrailingSlashRedirect))
+
+	r.HandleFunc(http.MethodGet, "/users/:id", func(w http.ResponseWriter, req *http.Request) {})
+	r.HandleFunc(http.MethodPost, "/users/:id", func(w http.ResponseWriter, req *http.Request) {})
+
+	testCases := []struct {
+		whenMethod     string
+		whenPath       string
+		expectStatus   int
+		expectLocation string
+	}{
+		{
+			whenMethod:   http.MethodGet,
+			whenPath:     "/users/1",
+			expectStatus: http.StatusOK,
+		},
+		{
+			whenMethod:     http.MethodGet,
+			whenPath:       "/users//1/?q=1",
+			expectStatus:   http.StatusMovedPermanently,
+			expectLocation: "/users/1?q=1",
+		},
+		{
+			whenMethod:     http.MethodPost,
+			whenPath:       "/users/1/",
+			expectStatus:   http.StatusPermanentRedirect,
+			expectLocation: "/users/1",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.whenMethod+" "+tc.whenPath, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(tc.whenMethod, tc.whenPath, nil))
+
+			assert.Equal(t, tc.expectStatus, w.Code)
+			assert.Equal(t, tc.expectLocation, w.Header().Get("Location"))
+		})
+	}
+}
+
+func TestRouter_EscapedPath(t *testing.T) {
+	r := NewRouter(WithEscapedPath())
+
+	r.HandleFunc(http.MethodGet, "/objects/:bucket/:key/meta", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("X-Key", PathParams(req)["key"])
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/objects/b/a%2Fc/meta", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "a/c", w.Header().Get("X-Key"))
+}
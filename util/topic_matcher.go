@@ -0,0 +1,241 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+)
+
+type (
+	TopicMatcher[T any] struct {
+		matcher *PathMatcher[[]topicRoute[T]]
+		options topicMatcherOptions
+	}
+
+	TopicMatcherOption func(o *topicMatcherOptions)
+
+	TopicMatch[T any] struct {
+		Value     T
+		Filter    string
+		Wildcards []string
+	}
+
+	topicMatcherOptions struct {
+		separator   byte
+		singleLevel string
+		multiLevel  string
+	}
+
+	topicSubscription[T any] struct {
+		filter string
+		value  T
+	}
+
+	topicRoute[T any] struct {
+		subscription *topicSubscription[T]
+		// slots maps each wildcard of the filter to a captured value, -1 when the multi-level wildcard was left out.
+		slots []int
+	}
+
+	topicVariant struct {
+		variant pathVariant
+		slots   []int
+	}
+)
+
+func WithTopicSeparator(separator byte) TopicMatcherOption {
+	return func(o *topicMatcherOptions) {
+		o.separator = separator
+	}
+}
+
+func WithSingleLevelWildcard(wildcard string) TopicMatcherOption {
+	return func(o *topicMatcherOptions) {
+		o.singleLevel = wildcard
+	}
+}
+
+func WithMultiLevelWildcard(wildcard string) TopicMatcherOption {
+	return func(o *topicMatcherOptions) {
+		o.multiLevel = wildcard
+	}
+}
+
+func NewTopicMatcher[T any](options ...TopicMatcherOption) *TopicMatcher[T] {
+	matcher := NewPathMatcher[[]topicRoute[T]]()
+	matcher.options.segmentParams = true
+
+	m := &TopicMatcher[T]{
+		matcher: matcher,
+		options: topicMatcherOptions{
+			separator:   '/',
+			singleLevel: "+",
+			multiLevel:  "#",
+		},
+	}
+	for _, option := range options {
+		option(&m.options)
+	}
+	return m
+}
+
+func (m *TopicMatcher[T]) Add(filter string, value T) {
+	if err := m.AddE(filter, value); err != nil {
+		panic(err)
+	}
+}
+
+func (m *TopicMatcher[T]) AddE(filter string, value T) error {
+	variants, err := m.parse(filter)
+	if err != nil {
+		return err
+	}
+
+	subscription := &topicSubscription[T]{filter: filter, value: value}
+	for _, v := range variants {
+		route := topicRoute[T]{subscription: subscription, slots: v.slots}
+		if n := m.matcher.find(v.variant.tokens); n != nil && n.pristinePath != "" {
+			n.value = append(n.value, route)
+		} else {
+			m.matcher.add(filter, v.variant, []topicRoute[T]{route}, nil)
+		}
+	}
+	return nil
+}
+
+func (m *TopicMatcher[T]) Remove(filter string) bool {
+	variants, err := m.parse(filter)
+	if err != nil {
+		return false
+	}
+
+	removed := false
+	for _, v := range variants {
+		n := m.matcher.find(v.variant.tokens)
+		if n == nil || n.pristinePath == "" {
+			continue
+		}
+
+		var rest []topicRoute[T]
+		for _, r := range n.value {
+			if r.subscription.filter != filter {
+				rest = append(rest, r)
+			}
+		}
+		if len(rest) == len(n.value) {
+			continue
+		}
+		removed = true
+
+		if len(rest) > 0 {
+			n.value = rest
+		} else {
+			m.matcher.removeVariant(n.pristinePath, v.variant, nil)
+		}
+	}
+	return removed
+}
+
+func (m *TopicMatcher[T]) MatchAll(topic string) []TopicMatch[T] {
+	var matches []TopicMatch[T]
+	visited := map[*topicSubscription[T]]bool{}
+	m.matcher.lookup(m.key(topic), nil, nil, func(route *route[[]topicRoute[T]], values []string) bool {
+		for _, r := range route.value {
+			// A filter with several multi-level wildcards may reach the topic in more than one way.
+			if visited[r.subscription] {
+				continue
+			}
+			visited[r.subscription] = true
+
+			wildcards := make([]string, len(r.slots))
+			for i, slot := range r.slots {
+				if slot >= 0 {
+					wildcards[i] = m.key(values[slot])
+				}
+			}
+			matches = append(matches, TopicMatch[T]{Value: r.subscription.value, Filter: r.subscription.filter, Wildcards: wildcards})
+		}
+		return false
+	})
+	return matches
+}
+
+func (m *TopicMatcher[T]) parse(filter string) ([]topicVariant, error) {
+	levels := strings.Split(filter, string(m.options.separator))
+
+	var multiLevels []int
+	for i, level := range levels {
+		switch {
+		case level == m.options.multiLevel:
+			multiLevels = append(multiLevels, i)
+		case level == m.options.singleLevel:
+		case strings.Contains(level, m.options.singleLevel) || strings.Contains(level, m.options.multiLevel):
+			return nil, fmt.Errorf("%w: %q has a wildcard inside level %d", ErrInvalidPattern, filter, i)
+		}
+	}
+
+	// Each multi-level wildcard either takes one or more levels or is left out with its separator.
+	var variants []topicVariant
+	shapes := map[string]bool{}
+	for mask := 0; mask < 1<<len(multiLevels); mask++ {
+		omitted := map[int]bool{}
+		for i, level := range multiLevels {
+			if mask&(1<<i) != 0 {
+				omitted[level] = true
+			}
+		}
+
+		var v topicVariant
+		count, captured := 0, 0
+		for i, level := range levels {
+			if omitted[i] {
+				v.slots = append(v.slots, -1)
+				continue
+			}
+			if count > 0 {
+				v.variant = v.variant.concat(pathVariant{tokens: []pathToken{{kind: staticKind, text: "/"}}})
+			}
+			count++
+
+			var token pathToken
+			switch level {
+			case m.options.multiLevel:
+				token = pathToken{kind: anyKind, name: level}
+				v.slots = append(v.slots, captured)
+				captured++
+			case m.options.singleLevel:
+				token = pathToken{kind: paramKind, name: level}
+				v.slots = append(v.slots, captured)
+				captured++
+			default:
+				token = pathToken{kind: staticKind, text: m.key(level)}
+			}
+			if token.kind != staticKind || token.text != "" {
+				v.variant = v.variant.concat(pathVariant{tokens: []pathToken{token}})
+			}
+		}
+
+		if shape := v.variant.shape(); !shapes[shape] {
+			shapes[shape] = true
+			variants = append(variants, v)
+		}
+	}
+	return variants, nil
+}
+
+// key swaps the separator with slashes, so topic levels become path segments. Swapping again gives the topic back.
+func (m *TopicMatcher[T]) key(topic string) string {
+	if m.options.separator == '/' {
+		return topic
+	}
+
+	b := []byte(topic)
+	for i, c := range b {
+		switch c {
+		case m.options.separator:
+			b[i] = '/'
+		case '/':
+			b[i] = m.options.separator
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,94 @@
+package util
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTopicMatcher_MatchAll(t *testing.T) {
+	testCases := []struct {
+		whenOptions     []TopicMatcherOption
+		whenFilters     []string
+		whenTopic       string
+		expectFilters   []string
+		expectWildcards [][]string
+	}{
+		{
+			whenFilters:     []string{"sensors/+/temp", "sensors/#", "sensors/kitchen/temp", "#", "sensors/+"},
+			whenTopic:       "sensors/kitchen/temp",
+			expectFilters:   []string{"sensors/kitchen/temp", "sensors/+/temp", "sensors/#", "#"},
+			expectWildcards: [][]string{{}, {"kitchen"}, {"kitchen/temp"}, {"sensors/kitchen/temp"}},
+		},
+		{
+			whenFilters:     []string{"sensors/#", "sensors/+"},
+			whenTopic:       "sensors",
+			expectFilters:   []string{"sensors/#"},
+			expectWildcards: [][]string{{""}},
+		},
+		{
+			whenFilters:     []string{"sensors/+/temp"},
+			whenTopic:       "sensors/a/b/temp",
+			expectFilters:   nil,
+			expectWildcards: nil,
+		},
+		{
+			whenOptions:     []TopicMatcherOption{WithTopicSeparator('.'), WithSingleLevelWildcard("*")},
+			whenFilters:     []string{"orders.*.created", "orders.#", "#.created", "orders.#.created", "payments.#"},
+			whenTopic:       "orders.eu/1.created",
+			expectFilters:   []string{"orders.*.created", "orders.#.created", "orders.#", "#.created"},
+			expectWildcards: [][]string{{"eu/1"}, {"eu/1"}, {"eu/1.created"}, {"orders.eu/1"}},
+		},
+		{
+			whenOptions:     []TopicMatcherOption{WithTopicSeparator('.'), WithSingleLevelWildcard("*")},
+			whenFilters:     []string{"#.a.#"},
+			whenTopic:       "a.a",
+			expectFilters:   []string{"#.a.#"},
+			expectWildcards: [][]string{{"", "a"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.whenTopic, func(t *testing.T) {
+			m := NewTopicMatcher[string](tc.whenOptions...)
+			for _, filter := range tc.whenFilters {
+				m.Add(filter, filter)
+			}
+
+			var filters []string
+			var wildcards [][]string
+			for _, match := range m.MatchAll(tc.whenTopic) {
+				assert.Equal(t, match.Filter, match.Value)
+				filters = append(filters, match.Filter)
+				wildcards = append(wildcards, match.Wildcards)
+			}
+			assert.Equal(t, tc.expectFilters, filters)
+			assert.Equal(t, tc.expectWildcards, wildcards)
+		})
+	}
+}
+
+func TestTopicMatcher_Remove(t *testing.T) {
+	m := NewTopicMatcher[int]()
+
+	m.Add("sensors/#", 1)
+	m.Add("sensors/#", 2)
+	m.Add("sensors", 3)
+
+	assert.Len(t, m.MatchAll("sensors"), 3)
+
+	assert.True(t, m.Remove("sensors/#"))
+	assert.False(t, m.Remove("sensors/#"))
+
+	matches := m.MatchAll("sensors")
+	assert.Len(t, matches, 1)
+	assert.Equal(t, 3, matches[0].Value)
+	assert.Len(t, m.MatchAll("sensors/kitchen"), 0)
+}
+
+func TestTopicMatcher_AddE(t *testing.T) {
+	m := NewTopicMatcher[int]()
+
+	assert.NoError(t, m.AddE("sensors/+/temp", 1))
+	assert.ErrorIs(t, m.AddE("sensors/kitchen+/temp", 1), ErrInvalidPattern)
+	assert.ErrorIs(t, m.AddE("sensors/#temp", 1), ErrInvalidPattern)
+}
@@ -0,0 +1,638 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"github.com/iancoleman/strcase"
+	"golang.org/x/exp/slices"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type (
+	URITemplate struct {
+		template string
+		parts    []uriTemplatePart
+		pattern  *regexp.Regexp
+	}
+
+	uriTemplatePart struct {
+		literal  string
+		op       uriOperator
+		varspecs []uriVarspec
+		// group is the submatch of the expression in pattern, zero for literals.
+		group int
+	}
+
+	uriOperator struct {
+		first    string
+		sep      string
+		named    bool
+		ifEmpty  string
+		reserved bool
+		// class holds the characters an expansion of the operator is made of, without first.
+		class string
+	}
+
+	uriVarspec struct {
+		name    string
+		prefix  int
+		explode bool
+	}
+
+	uriValue struct {
+		text  string
+		list  []string
+		pairs [][2]string
+	}
+
+	uriMatch struct {
+		values   map[string]any
+		prefixed map[string]bool
+	}
+)
+
+const (
+	uriUnreservedClass = `A-Za-z0-9\-._~%`
+	uriReservedClass   = `:/?#\[\]@!$&'()*+,;=`
+	upperHex           = "0123456789ABCDEF"
+)
+
+var ErrInvalidTemplate = errors.New("invalid template")
+
+var uriOperators = map[byte]uriOperator{
+	0:   {sep: ",", class: uriUnreservedClass + ",="},
+	'+': {sep: ",", reserved: true, class: uriUnreservedClass + uriReservedClass},
+	'#': {first: "#", sep: ",", reserved: true, class: uriUnreservedClass + uriReservedClass},
+	'.': {first: ".", sep: ".", class: uriUnreservedClass + ",="},
+	'/': {first: "/", sep: "/", class: uriUnreservedClass + ",=/"},
+	';': {first: ";", sep: ";", named: true, class: uriUnreservedClass + ",=;"},
+	'?': {first: "?", sep: "&", named: true, ifEmpty: "=", class: uriUnreservedClass + ",=&"},
+	'&': {first: "&", sep: "&", named: true, ifEmpty: "=", class: uriUnreservedClass + ",=&"},
+}
+
+func ParseURITemplate(template string) (*URITemplate, error) {
+	t := &URITemplate{template: template}
+
+	var pattern strings.Builder
+	pattern.WriteByte('^')
+
+	groups := 0
+	for pos := 0; pos < len(template); {
+		start := strings.IndexByte(template[pos:], '{')
+		if start < 0 {
+			start = len(template)
+		} else {
+			start += pos
+		}
+		if end := strings.IndexByte(template[pos:start], '}'); end >= 0 {
+			return nil, fmt.Errorf("%w: %q has an unbalanced expression at %d", ErrInvalidTemplate, template, pos+end)
+		}
+		if start > pos {
+			var literal strings.Builder
+			encodeURIValue(&literal, template[pos:start], true)
+			t.parts = append(t.parts, uriTemplatePart{literal: literal.String()})
+			pattern.WriteString(regexp.QuoteMeta(literal.String()))
+		}
+		if start == len(template) {
+			break
+		}
+
+		end := strings.IndexByte(template[start:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("%w: %q has an unterminated expression at %d", ErrInvalidTemplate, template, start)
+		}
+		end += start
+
+		part, err := parseURIExpression(template, start, template[start+1:end])
+		if err != nil {
+			return nil, err
+		}
+		groups++
+		part.group = groups
+		t.parts = append(t.parts, part)
+
+		body := "([" + part.op.class + "]*?)"
+		if part.op.first != "" {
+			body = "(?:" + regexp.QuoteMeta(part.op.first) + body + ")?"
+		}
+		pattern.WriteString(body)
+
+		pos = end + 1
+	}
+
+	pattern.WriteByte('$')
+	t.pattern = regexp.MustCompile(pattern.String())
+
+	return t, nil
+}
+
+func parseURIExpression(template string, start int, expression string) (uriTemplatePart, error) {
+	part := uriTemplatePart{op: uriOperators[0]}
+	if expression != "" {
+		c := expression[0]
+		if op, ok := uriOperators[c]; ok && c != 0 {
+			part.op = op
+			expression = expression[1:]
+		} else if strings.IndexByte("=,!@|", c) >= 0 {
+			return uriTemplatePart{}, fmt.Errorf("%w: %q uses the reserved operator %q at %d", ErrInvalidTemplate, template, c, start+1)
+		}
+	}
+	if expression == "" {
+		return uriTemplatePart{}, fmt.Errorf("%w: %q has an empty expression at %d", ErrInvalidTemplate, template, start)
+	}
+
+	for _, varspec := range strings.Split(expression, ",") {
+		spec := uriVarspec{name: varspec}
+		if strings.HasSuffix(varspec, "*") {
+			spec.name = varspec[:len(varspec)-1]
+			spec.explode = true
+		} else if i := strings.IndexByte(varspec, ':'); i >= 0 {
+			spec.name = varspec[:i]
+			prefix, err := strconv.Atoi(varspec[i+1:])
+			if err != nil || prefix <= 0 || prefix >= 10000 || len(varspec[i+1:]) > 4 {
+				return uriTemplatePart{}, fmt.Errorf("%w: %q has an invalid prefix modifier in %q", ErrInvalidTemplate, template, varspec)
+			}
+			spec.prefix = prefix
+		}
+		if !isURIVarname(spec.name) {
+			return uriTemplatePart{}, fmt.Errorf("%w: %q has an invalid variable name %q", ErrInvalidTemplate, template, spec.name)
+		}
+		part.varspecs = append(part.varspecs, spec)
+	}
+
+	return part, nil
+}
+
+func (t *URITemplate) Expand(values any) (string, error) {
+	var sb strings.Builder
+	for _, part := range t.parts {
+		if part.varspecs == nil {
+			sb.WriteString(part.literal)
+			continue
+		}
+		if err := part.expand(&sb, values); err != nil {
+			return "", fmt.Errorf("%w in %q", err, t.template)
+		}
+	}
+	return sb.String(), nil
+}
+
+func (t *URITemplate) Match(uri string) (bool, map[string]any) {
+	index := t.pattern.FindStringSubmatchIndex(uri)
+	if index == nil {
+		return false, nil
+	}
+
+	m := uriMatch{values: map[string]any{}, prefixed: map[string]bool{}}
+	for _, part := range t.parts {
+		if part.varspecs == nil || index[2*part.group] < 0 {
+			continue
+		}
+		body := uri[index[2*part.group]:index[2*part.group+1]]
+		if !part.extract(body, &m) {
+			return false, nil
+		}
+	}
+	return true, m.nest()
+}
+
+func (t *URITemplate) Variables() []string {
+	var names []string
+	for _, part := range t.parts {
+		for _, spec := range part.varspecs {
+			if !slices.Contains(names, spec.name) {
+				names = append(names, spec.name)
+			}
+		}
+	}
+	return names
+}
+
+func (t *URITemplate) String() string {
+	return t.template
+}
+
+func (p uriTemplatePart) expand(sb *strings.Builder, values any) error {
+	first := true
+	for _, spec := range p.varspecs {
+		value, ok := lookupURIValue(values, spec.name)
+		if !ok {
+			continue
+		}
+		if first {
+			sb.WriteString(p.op.first)
+			first = false
+		} else {
+			sb.WriteString(p.op.sep)
+		}
+
+		if value.list == nil && value.pairs == nil {
+			text := value.text
+			if spec.prefix > 0 && utf8.RuneCountInString(text) > spec.prefix {
+				text = string([]rune(text)[:spec.prefix])
+			}
+			if p.op.named {
+				sb.WriteString(spec.name)
+				if text == "" {
+					sb.WriteString(p.op.ifEmpty)
+					continue
+				}
+				sb.WriteByte('=')
+			}
+			encodeURIValue(sb, text, p.op.reserved)
+			continue
+		}
+
+		if spec.prefix > 0 {
+			return fmt.Errorf("%w: %q has a prefix modifier but a composite value", ErrInvalidParam, spec.name)
+		}
+
+		if !spec.explode {
+			if p.op.named {
+				sb.WriteString(spec.name)
+				sb.WriteByte('=')
+			}
+			for i, item := range value.list {
+				if i > 0 {
+					sb.WriteByte(',')
+				}
+				encodeURIValue(sb, item, p.op.reserved)
+			}
+			for i, pair := range value.pairs {
+				if i > 0 {
+					sb.WriteByte(',')
+				}
+				encodeURIValue(sb, pair[0], p.op.reserved)
+				sb.WriteByte(',')
+				encodeURIValue(sb, pair[1], p.op.reserved)
+			}
+			continue
+		}
+
+		for i, item := range value.list {
+			if i > 0 {
+				sb.WriteString(p.op.sep)
+			}
+			if p.op.named {
+				sb.WriteString(spec.name)
+				if item == "" {
+					sb.WriteString(p.op.ifEmpty)
+					continue
+				}
+				sb.WriteByte('=')
+			}
+			encodeURIValue(sb, item, p.op.reserved)
+		}
+		for i, pair := range value.pairs {
+			if i > 0 {
+				sb.WriteString(p.op.sep)
+			}
+			encodeURIValue(sb, pair[0], p.op.reserved)
+			if p.op.named && pair[1] == "" {
+				sb.WriteString(p.op.ifEmpty)
+				continue
+			}
+			sb.WriteByte('=')
+			encodeURIValue(sb, pair[1], p.op.reserved)
+		}
+	}
+	return nil
+}
+
+func (p uriTemplatePart) extract(body string, m *uriMatch) bool {
+	if p.op.named {
+		return p.extractNamed(body, m)
+	}
+
+	var pieces []string
+	if body != "" || p.op.first != "" {
+		pieces = strings.Split(body, p.op.sep)
+	}
+
+	// Undefined variables leave no trace in unnamed expressions, so pieces are given out in order and
+	// the first exploded variable, or else the last one, takes the pieces that are left over.
+	taker := len(p.varspecs) - 1
+	for i, spec := range p.varspecs {
+		if spec.explode {
+			taker = i
+			break
+		}
+	}
+	excess := len(pieces) - len(p.varspecs)
+
+	for i, spec := range p.varspecs {
+		if len(pieces) == 0 {
+			break
+		}
+		n := 1
+		if i == taker && excess > 0 {
+			n += excess
+		}
+		taken := pieces[:n]
+		pieces = pieces[n:]
+
+		if !spec.explode {
+			value, ok := decodeURIList(strings.Join(taken, p.op.sep))
+			if !ok {
+				return false
+			}
+			m.assign(spec, value)
+			continue
+		}
+
+		var list []string
+		pairs := map[string]string{}
+		for _, piece := range taken {
+			key, value, found := strings.Cut(piece, "=")
+			if found {
+				k, ok := decodeURIValue(key)
+				v, ok2 := decodeURIValue(value)
+				if !ok || !ok2 {
+					return false
+				}
+				pairs[k] = v
+				continue
+			}
+			v, ok := decodeURIValue(piece)
+			if !ok {
+				return false
+			}
+			list = append(list, v)
+		}
+		if len(pairs) > 0 {
+			for _, item := range list {
+				pairs[item] = ""
+			}
+			m.assign(spec, pairs)
+		} else {
+			m.assign(spec, list)
+		}
+	}
+	return true
+}
+
+func (p uriTemplatePart) extractNamed(body string, m *uriMatch) bool {
+	lists := map[string][]string{}
+	pairs := map[string]map[string]string{}
+
+	var current *uriVarspec
+	for _, piece := range strings.Split(body, p.op.sep) {
+		rawName, rawValue, _ := strings.Cut(piece, "=")
+		name, ok := decodeURIValue(rawName)
+		if !ok {
+			return false
+		}
+
+		if spec := p.varspec(name); spec != nil {
+			current = spec
+			if spec.explode {
+				value, ok := decodeURIValue(rawValue)
+				if !ok {
+					return false
+				}
+				lists[name] = append(lists[name], value)
+				continue
+			}
+			value, ok := decodeURIList(rawValue)
+			if !ok {
+				return false
+			}
+			m.assign(*spec, value)
+			continue
+		}
+
+		// Any other name is a key of an exploded associative array.
+		if current == nil || !current.explode {
+			if current = p.firstExploded(); current == nil {
+				return false
+			}
+		}
+		value, ok := decodeURIValue(rawValue)
+		if !ok {
+			return false
+		}
+		if pairs[current.name] == nil {
+			pairs[current.name] = map[string]string{}
+		}
+		pairs[current.name][name] = value
+	}
+
+	for _, spec := range p.varspecs {
+		if !spec.explode {
+			continue
+		}
+		if pairs[spec.name] != nil {
+			m.assign(spec, pairs[spec.name])
+		} else if lists[spec.name] != nil {
+			m.assign(spec, lists[spec.name])
+		}
+	}
+	return true
+}
+
+func (p uriTemplatePart) varspec(name string) *uriVarspec {
+	for i := range p.varspecs {
+		if p.varspecs[i].name == name {
+			return &p.varspecs[i]
+		}
+	}
+	return nil
+}
+
+func (p uriTemplatePart) firstExploded() *uriVarspec {
+	for i := range p.varspecs {
+		if p.varspecs[i].explode {
+			return &p.varspecs[i]
+		}
+	}
+	return nil
+}
+
+func (m *uriMatch) assign(spec uriVarspec, value any) {
+	// A prefix only holds the start of the value, any full occurrence of the variable wins over it.
+	if spec.prefix > 0 {
+		if _, ok := m.values[spec.name]; ok {
+			return
+		}
+		m.prefixed[spec.name] = true
+	} else if _, ok := m.values[spec.name]; ok && !m.prefixed[spec.name] {
+		return
+	} else {
+		delete(m.prefixed, spec.name)
+	}
+	m.values[spec.name] = value
+}
+
+func (m *uriMatch) nest() map[string]any {
+	names := make([]string, 0, len(m.values))
+	for name := range m.values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	res := map[string]any{}
+	for _, name := range names {
+		keys := strings.Split(name, ".")
+		current := res
+		for _, key := range keys[:len(keys)-1] {
+			next, ok := current[key].(map[string]any)
+			if !ok {
+				next = map[string]any{}
+				current[key] = next
+			}
+			current = next
+		}
+		current[keys[len(keys)-1]] = m.values[name]
+	}
+	return res
+}
+
+func lookupURIValue(values any, name string) (uriValue, bool) {
+	if values == nil {
+		return uriValue{}, false
+	}
+	value, ok := Get[any](values, name)
+	if !ok {
+		return uriValue{}, false
+	}
+
+	if s, ok := value.(fmt.Stringer); ok {
+		return uriValue{text: s.String()}, true
+	}
+	if b, ok := value.([]byte); ok {
+		return uriValue{text: string(b)}, true
+	}
+
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return uriValue{}, false
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		var list []string
+		for i := 0; i < v.Len(); i++ {
+			if text, ok := uriText(v.Index(i)); ok {
+				list = append(list, text)
+			}
+		}
+		return uriValue{list: list}, len(list) > 0
+	case reflect.Map:
+		var pairs [][2]string
+		for _, k := range v.MapKeys() {
+			if text, ok := uriText(v.MapIndex(k)); ok {
+				pairs = append(pairs, [2]string{fmt.Sprint(k.Interface()), text})
+			}
+		}
+		sort.Slice(pairs, func(i, j int) bool {
+			return pairs[i][0] < pairs[j][0]
+		})
+		return uriValue{pairs: pairs}, len(pairs) > 0
+	case reflect.Struct:
+		var pairs [][2]string
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			if text, ok := uriText(v.Field(i)); ok {
+				pairs = append(pairs, [2]string{strcase.ToLowerCamel(field.Name), text})
+			}
+		}
+		return uriValue{pairs: pairs}, len(pairs) > 0
+	}
+
+	text, ok := uriText(v)
+	return uriValue{text: text}, ok
+}
+
+func uriText(v reflect.Value) (string, bool) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return "", false
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return "", false
+	}
+	return fmt.Sprint(v.Interface()), true
+}
+
+func encodeURIValue(sb *strings.Builder, value string, reserved bool) {
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if isURIUnreserved(c) {
+			sb.WriteByte(c)
+			continue
+		}
+		if reserved {
+			if strings.IndexByte(":/?#[]@!$&'()*+,;=", c) >= 0 {
+				sb.WriteByte(c)
+				continue
+			}
+			// Reserved expansion keeps the characters that are already percent encoded.
+			if c == '%' && i+2 < len(value) && isHex(value[i+1]) && isHex(value[i+2]) {
+				sb.WriteString(value[i : i+3])
+				i += 2
+				continue
+			}
+		}
+		sb.WriteByte('%')
+		sb.WriteByte(upperHex[c>>4])
+		sb.WriteByte(upperHex[c&15])
+	}
+}
+
+func decodeURIValue(value string) (string, bool) {
+	res, err := url.PathUnescape(value)
+	return res, err == nil
+}
+
+func decodeURIList(value string) (any, bool) {
+	items := strings.Split(value, ",")
+	for i, item := range items {
+		decoded, ok := decodeURIValue(item)
+		if !ok {
+			return nil, false
+		}
+		items[i] = decoded
+	}
+	if len(items) == 1 {
+		return items[0], true
+	}
+	return items, true
+}
+
+func isURIVarname(name string) bool {
+	if name == "" || name[0] == '.' || name[len(name)-1] == '.' || strings.Contains(name, "..") {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if c == '%' {
+			if i+2 >= len(name) || !isHex(name[i+1]) || !isHex(name[i+2]) {
+				return false
+			}
+			i += 2
+			continue
+		}
+		if c != '.' && !isParamNameChar(c) {
+			return false
+		}
+	}
+	return true
+}
+
+func isURIUnreserved(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') || c == '-' || c == '.' || c == '_' || c == '~'
+}
@@ -0,0 +1,277 @@
+package util
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestURITemplate_Expand(t *testing.T) {
+	values := map[string]any{
+		"count": []string{"one", "two", "three"},
+		"dom":   []string{"example", "com"},
+		"dub":   "me/too",
+		"hello": "Hello World!",
+		"half":  "50%",
+		"var":   "value",
+		"who":   "fred",
+		"base":  "http://example.com/home/",
+		"path":  "/foo/bar",
+		"list":  []string{"red", "green", "blue"},
+		"keys":  map[string]string{"semi": ";", "dot": ".", "comma": ","},
+		"v":     6,
+		"x":     1024,
+		"y":     768,
+		"empty": "",
+		"undef": nil,
+		"user": struct {
+			Name string
+			Age  int
+		}{Name: "Jo Ann", Age: 30},
+	}
+
+	testCases := []struct {
+		whenTemplate string
+		expectResult string
+		expectErr    error
+	}{
+		// Level 1
+		{whenTemplate: "{var}", expectResult: "value"},
+		{whenTemplate: "{hello}", expectResult: "Hello%20World%21"},
+		{whenTemplate: "{half}", expectResult: "50%25"},
+		{whenTemplate: "O{empty}X", expectResult: "OX"},
+		{whenTemplate: "O{undef}X", expectResult: "OX"},
+		// Level 2
+		{whenTemplate: "{+var}", expectResult: "value"},
+		{whenTemplate: "{+hello}", expectResult: "Hello%20World!"},
+		{whenTemplate: "{+half}", expectResult: "50%25"},
+		{whenTemplate: "{base}index", expectResult: "http%3A%2F%2Fexample.com%2Fhome%2Findex"},
+		{whenTemplate: "{+base}index", expectResult: "http://example.com/home/index"},
+		{whenTemplate: "{+path}/here", expectResult: "/foo/bar/here"},
+		{whenTemplate: "here?ref={+path}", expectResult: "here?ref=/foo/bar"},
+		{whenTemplate: "X{#var}", expectResult: "X#value"},
+		{whenTemplate: "X{#hello}", expectResult: "X#Hello%20World!"},
+		// Level 3
+		{whenTemplate: "map?{x,y}", expectResult: "map?1024,768"},
+		{whenTemplate: "{x,hello,y}", expectResult: "1024,Hello%20World%21,768"},
+		{whenTemplate: "{+x,hello,y}", expectResult: "1024,Hello%20World!,768"},
+		{whenTemplate: "{+path,x}/here", expectResult: "/foo/bar,1024/here"},
+		{whenTemplate: "{#x,hello,y}", expectResult: "#1024,Hello%20World!,768"},
+		{whenTemplate: "X{.var}", expectResult: "X.value"},
+		{whenTemplate: "X{.x,y}", expectResult: "X.1024.768"},
+		{whenTemplate: "{/var}", expectResult: "/value"},
+		{whenTemplate: "{/var,x}/here", expectResult: "/value/1024/here"},
+		{whenTemplate: "{;x,y}", expectResult: ";x=1024;y=768"},
+		{whenTemplate: "{;x,y,empty}", expectResult: ";x=1024;y=768;empty"},
+		{whenTemplate: "{?x,y}", expectResult: "?x=1024&y=768"},
+		{whenTemplate: "{?x,y,empty}", expectResult: "?x=1024&y=768&empty="},
+		{whenTemplate: "?fixed=yes{&x}", expectResult: "?fixed=yes&x=1024"},
+		{whenTemplate: "{&x,y,empty}", expectResult: "&x=1024&y=768&empty="},
+		// Level 4
+		{whenTemplate: "{var:3}", expectResult: "val"},
+		{whenTemplate: "{var:30}", expectResult: "value"},
+		{whenTemplate: "{list}", expectResult: "red,green,blue"},
+		{whenTemplate: "{list*}", expectResult: "red,green,blue"},
+		{whenTemplate: "{keys}", expectResult: "comma,%2C,dot,.,semi,%3B"},
+		{whenTemplate: "{keys*}", expectResult: "comma=%2C,dot=.,semi=%3B"},
+		{whenTemplate: "{+path:6}/here", expectResult: "/foo/b/here"},
+		{whenTemplate: "{+list}", expectResult: "red,green,blue"},
+		{whenTemplate: "{+keys*}", expectResult: "comma=,,dot=.,semi=;"},
+		{whenTemplate: "{#path:6}/here", expectResult: "#/foo/b/here"},
+		{whenTemplate: "{#keys}", expectResult: "#comma,,,dot,.,semi,;"},
+		{whenTemplate: "X{.list*}", expectResult: "X.red.green.blue"},
+		{whenTemplate: "X{.keys*}", expectResult: "X.comma=%2C.dot=..semi=%3B"},
+		{whenTemplate: "{/var:1,var}", expectResult: "/v/value"},
+		{whenTemplate: "{/list*,path:4}", expectResult: "/red/green/blue/%2Ffoo"},
+		{whenTemplate: "{/keys*}", expectResult: "/comma=%2C/dot=./semi=%3B"},
+		{whenTemplate: "{;hello:5}", expectResult: ";hello=Hello"},
+		{whenTemplate: "{;list*}", expectResult: ";list=red;list=green;list=blue"},
+		{whenTemplate: "{;keys*}", expectResult: ";comma=%2C;dot=.;semi=%3B"},
+		{whenTemplate: "{?var:3}", expectResult: "?var=val"},
+		{whenTemplate: "{?list}", expectResult: "?list=red,green,blue"},
+		{whenTemplate: "{?list*}", expectResult: "?list=red&list=green&list=blue"},
+		{whenTemplate: "{?keys}", expectResult: "?keys=comma,%2C,dot,.,semi,%3B"},
+		{whenTemplate: "{?keys*}", expectResult: "?comma=%2C&dot=.&semi=%3B"},
+		{whenTemplate: "{&var:3}", expectResult: "&var=val"},
+		{whenTemplate: "{&list*}", expectResult: "&list=red&list=green&list=blue"},
+		{whenTemplate: "{count}", expectResult: "one,two,three"},
+		{whenTemplate: "{/count*}", expectResult: "/one/two/three"},
+		{whenTemplate: "{.dom*}", expectResult: ".example.com"},
+		{whenTemplate: "{/who,dub}", expectResult: "/fred/me%2Ftoo"},
+		{whenTemplate: "{?v,who}", expectResult: "?v=6&who=fred"},
+		// Nested values
+		{whenTemplate: "/users/{user.name}{?user.age}", expectResult: "/users/Jo%20Ann?user.age=30"},
+		{whenTemplate: "/users{?user*}", expectResult: "/users?name=Jo%20Ann&age=30"},
+		{whenTemplate: "{list:3}", expectErr: ErrInvalidParam},
+	}
+
+	for _, tc := range testCases {
+		template, err := ParseURITemplate(tc.whenTemplate)
+		assert.NoError(t, err)
+
+		res, err := template.Expand(values)
+		if tc.expectErr != nil {
+			assert.ErrorIs(t, err, tc.expectErr, tc.whenTemplate)
+			continue
+		}
+		assert.NoError(t, err, tc.whenTemplate)
+		assert.Equal(t, tc.expectResult, res, tc.whenTemplate)
+	}
+}
+
+func TestURITemplate_Match(t *testing.T) {
+	testCases := []struct {
+		whenTemplate string
+		whenURI      string
+		expectOk     bool
+		expectValues map[string]any
+	}{
+		{
+			whenTemplate: "/users{/id}{?fields,limit}",
+			whenURI:      "/users/1?fields=name,email&limit=10",
+			expectOk:     true,
+			expectValues: map[string]any{"id": "1", "fields": []string{"name", "email"}, "limit": "10"},
+		},
+		{
+			whenTemplate: "/users{/id}{?fields,limit}",
+			whenURI:      "/users?limit=10",
+			expectOk:     true,
+			expectValues: map[string]any{"limit": "10"},
+		},
+		{
+			whenTemplate: "/users{/id}{?fields,limit}",
+			whenURI:      "/users/1?sort=name",
+			expectOk:     false,
+		},
+		{
+			whenTemplate: "/users{/id}",
+			whenURI:      "/posts/1",
+			expectOk:     false,
+		},
+		{
+			whenTemplate: "{+path}/here",
+			whenURI:      "/foo/bar/here",
+			expectOk:     true,
+			expectValues: map[string]any{"path": "/foo/bar"},
+		},
+		{
+			whenTemplate: "{hello}",
+			whenURI:      "Hello%20World%21",
+			expectOk:     true,
+			expectValues: map[string]any{"hello": "Hello World!"},
+		},
+		{
+			whenTemplate: "X{.x,y}",
+			whenURI:      "X.1024.768",
+			expectOk:     true,
+			expectValues: map[string]any{"x": "1024", "y": "768"},
+		},
+		{
+			whenTemplate: "{/list*,path:4}",
+			whenURI:      "/red/green/blue/%2Ffoo",
+			expectOk:     true,
+			expectValues: map[string]any{"list": []string{"red", "green", "blue"}, "path": "/foo"},
+		},
+		{
+			whenTemplate: "{;list*}",
+			whenURI:      ";list=red;list=green;list=blue",
+			expectOk:     true,
+			expectValues: map[string]any{"list": []string{"red", "green", "blue"}},
+		},
+		{
+			whenTemplate: "{?keys*}",
+			whenURI:      "?comma=%2C&dot=.&semi=%3B",
+			expectOk:     true,
+			expectValues: map[string]any{"keys": map[string]string{"comma": ",", "dot": ".", "semi": ";"}},
+		},
+		{
+			whenTemplate: "{/keys*}",
+			whenURI:      "/comma=%2C/dot=./semi=%3B",
+			expectOk:     true,
+			expectValues: map[string]any{"keys": map[string]string{"comma": ",", "dot": ".", "semi": ";"}},
+		},
+		{
+			whenTemplate: "{;x,y,empty}",
+			whenURI:      ";x=1024;y=768;empty",
+			expectOk:     true,
+			expectValues: map[string]any{"x": "1024", "y": "768", "empty": ""},
+		},
+		{
+			whenTemplate: "{?x}{&y}",
+			whenURI:      "?x=1024&y=768",
+			expectOk:     true,
+			expectValues: map[string]any{"x": "1024", "y": "768"},
+		},
+		{
+			whenTemplate: "{/var:1,var}",
+			whenURI:      "/v/value",
+			expectOk:     true,
+			expectValues: map[string]any{"var": "value"},
+		},
+		{
+			whenTemplate: "/users/{user.name}{?user.age}",
+			whenURI:      "/users/Jo%20Ann?user.age=30",
+			expectOk:     true,
+			expectValues: map[string]any{"user": map[string]any{"name": "Jo Ann", "age": "30"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		template, err := ParseURITemplate(tc.whenTemplate)
+		assert.NoError(t, err)
+
+		ok, values := template.Match(tc.whenURI)
+		assert.Equal(t, tc.expectOk, ok, tc.whenURI)
+		assert.Equal(t, tc.expectValues, values, tc.whenURI)
+	}
+}
+
+func TestURITemplate_MatchExpanded(t *testing.T) {
+	template, err := ParseURITemplate("/search{/scope*}{?q,tags,page}{#section}")
+	assert.NoError(t, err)
+
+	values := map[string]any{
+		"scope":   []string{"docs", "api"},
+		"q":       "a&b",
+		"tags":    []string{"x", "y"},
+		"section": "top",
+	}
+	uri, err := template.Expand(values)
+	assert.NoError(t, err)
+	assert.Equal(t, "/search/docs/api?q=a%26b&tags=x,y#top", uri)
+
+	ok, res := template.Match(uri)
+	assert.True(t, ok)
+	assert.Equal(t, values, res)
+}
+
+func TestParseURITemplate(t *testing.T) {
+	testCases := []struct {
+		whenTemplate    string
+		expectVariables []string
+		expectErr       error
+	}{
+		{whenTemplate: "/users{/id}{?fields,limit}", expectVariables: []string{"id", "fields", "limit"}},
+		{whenTemplate: "{/var:1,var}", expectVariables: []string{"var"}},
+		{whenTemplate: "/users/{user.name}", expectVariables: []string{"user.name"}},
+		{whenTemplate: "/static", expectVariables: nil},
+		{whenTemplate: "/users/{id", expectErr: ErrInvalidTemplate},
+		{whenTemplate: "/users/id}", expectErr: ErrInvalidTemplate},
+		{whenTemplate: "/users/{}", expectErr: ErrInvalidTemplate},
+		{whenTemplate: "/users/{=id}", expectErr: ErrInvalidTemplate},
+		{whenTemplate: "/users/{id:0}", expectErr: ErrInvalidTemplate},
+		{whenTemplate: "/users/{id:10000}", expectErr: ErrInvalidTemplate},
+		{whenTemplate: "/users/{user..name}", expectErr: ErrInvalidTemplate},
+		{whenTemplate: "/users/{user-name}", expectErr: ErrInvalidTemplate},
+	}
+
+	for _, tc := range testCases {
+		template, err := ParseURITemplate(tc.whenTemplate)
+		if tc.expectErr != nil {
+			assert.ErrorIs(t, err, tc.expectErr, tc.whenTemplate)
+			continue
+		}
+		assert.NoError(t, err, tc.whenTemplate)
+		assert.Equal(t, tc.whenTemplate, template.String())
+		assert.Equal(t, tc.expectVariables, template.Variables())
+	}
+}
@@ -0,0 +1,457 @@
+package util
+
+import (
+	"fmt"
+	"golang.org/x/exp/slices"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+type (
+	URLMatcher[T any] struct {
+		matcher *PathMatcher[[]urlRoute[T]]
+	}
+
+	URLParams struct {
+		Scheme map[string]string
+		Host   map[string]string
+		Port   map[string]string
+		Path   map[string]string
+		Query  map[string]string
+	}
+
+	urlComponent uint8
+
+	urlRoute[T any] struct {
+		pattern string
+		value   T
+		query   []urlQuery
+		// slots tells the component of each captured value, names their param names.
+		slots    []urlComponent
+		names    []string
+		defaults [urlComponents]map[string]string
+	}
+
+	urlQuery struct {
+		key   string
+		value *Pattern
+	}
+)
+
+const (
+	schemeComponent urlComponent = iota
+	hostComponent
+	portComponent
+	pathComponent
+	urlComponents
+)
+
+// urlBoundary separates the components of a url in the keys of the tree.
+const urlBoundary = "/\x00"
+
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+	"ws":    "80",
+	"wss":   "443",
+	"ftp":   "21",
+}
+
+func NewURLMatcher[T any]() *URLMatcher[T] {
+	return &URLMatcher[T]{
+		matcher: NewPathMatcher[[]urlRoute[T]](),
+	}
+}
+
+func (m *URLMatcher[T]) Add(pattern string, value T) {
+	if err := m.add(pattern, value, false); err != nil {
+		panic(err)
+	}
+}
+
+func (m *URLMatcher[T]) AddE(pattern string, value T) error {
+	return m.add(pattern, value, true)
+}
+
+func (m *URLMatcher[T]) Remove(pattern string) bool {
+	routes, variants, err := m.parse(pattern)
+	if err != nil {
+		return false
+	}
+
+	removed := false
+	for i, v := range variants {
+		n := m.matcher.find(v.tokens)
+		if n == nil || n.pristinePath == "" {
+			continue
+		}
+
+		var rest []urlRoute[T]
+		for _, r := range n.value {
+			if r.pattern != routes[i].pattern {
+				rest = append(rest, r)
+			}
+		}
+		if len(rest) == len(n.value) {
+			continue
+		}
+		removed = true
+
+		if len(rest) > 0 {
+			n.value = rest
+		} else {
+			m.matcher.removeVariant(n.pristinePath, v, nil)
+		}
+	}
+	return removed
+}
+
+func (m *URLMatcher[T]) Match(rawURL string) (T, string, URLParams) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		var zero T
+		return zero, "", URLParams{}
+	}
+	return m.MatchURL(u)
+}
+
+func (m *URLMatcher[T]) MatchURL(u *url.URL) (T, string, URLParams) {
+	scheme := strings.ToLower(u.Scheme)
+	host := u.Hostname()
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	port := u.Port()
+	if port == defaultPorts[scheme] {
+		port = ""
+	}
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+
+	var query url.Values
+	var res *urlRoute[T]
+	var params URLParams
+	key := scheme + urlBoundary + reverseHost(host) + urlBoundary + port + urlBoundary + path
+	m.matcher.lookup(key, nil, nil, func(node *route[[]urlRoute[T]], values []string) bool {
+		for i := range node.value {
+			r := &node.value[i]
+			if query == nil {
+				query = u.Query()
+			}
+			queryParams, ok := r.matchQuery(query)
+			if !ok {
+				continue
+			}
+
+			res, params = r, r.params(values)
+			params.Query = queryParams
+			return true
+		}
+		return false
+	})
+	if res == nil {
+		var zero T
+		return zero, "", URLParams{}
+	}
+	return res.value, res.pattern, params
+}
+
+func (m *URLMatcher[T]) add(pattern string, value T, strict bool) error {
+	routes, variants, err := m.parse(pattern)
+	if err != nil {
+		return err
+	}
+
+	if strict {
+		for i, v := range variants {
+			if hasAdjacentParams(v.tokens) {
+				return &RouteConflictError{Pattern: pattern, Reason: "ambiguous adjacent params"}
+			}
+			n := m.matcher.find(v.tokens)
+			if n == nil || n.pristinePath == "" {
+				continue
+			}
+			for _, r := range n.value {
+				if r.querySignature() != routes[i].querySignature() {
+					continue
+				}
+				if r.pattern == pattern {
+					return &RouteConflictError{Pattern: pattern, Existing: r.pattern, Reason: "duplicate route"}
+				}
+				return &RouteConflictError{Pattern: pattern, Existing: r.pattern, Reason: "ambiguous route"}
+			}
+		}
+	}
+
+	for i, v := range variants {
+		r := routes[i]
+		r.value = value
+
+		n := m.matcher.find(v.tokens)
+		if n == nil || n.pristinePath == "" {
+			m.matcher.add(pattern, v, []urlRoute[T]{r}, nil)
+			continue
+		}
+
+		// Adding a pattern again replaces its value, like PathMatcher.Add.
+		existing := n.value
+		if j := slices.IndexFunc(existing, func(e urlRoute[T]) bool {
+			return e.pattern == pattern
+		}); j >= 0 {
+			existing[j] = r
+			continue
+		}
+
+		// Routes asking for more of the query are tried first.
+		j := sort.Search(len(existing), func(j int) bool {
+			return len(existing[j].query) < len(r.query)
+		})
+		next := make([]urlRoute[T], 0, len(existing)+1)
+		next = append(next, existing[:j]...)
+		next = append(next, r)
+		n.value = append(next, existing[j:]...)
+	}
+	return nil
+}
+
+// parse returns the tree variants of a url pattern with the route of each.
+func (m *URLMatcher[T]) parse(pattern string) ([]urlRoute[T], []pathVariant, error) {
+	// net/url rejects wildcard ports and params in hosts, so the pattern is split by hand.
+	scheme, rest, ok := strings.Cut(pattern, "://")
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: %q has no scheme", ErrInvalidPattern, pattern)
+	}
+
+	authority := rest
+	path := ""
+	if i := indexOutsideConstraint(rest, "/?"); i >= 0 {
+		authority, path = rest[:i], rest[i:]
+	}
+	rawQuery := ""
+	if i := indexOutsideConstraint(path, "?"); i >= 0 {
+		path, rawQuery = path[:i], path[i+1:]
+	}
+	if path == "" {
+		path = "/"
+	}
+
+	host, port := authority, ""
+	if i := strings.LastIndexByte(authority, ':'); i > 0 && !strings.Contains(authority[i:], "]") && isPortPattern(authority[i+1:]) {
+		host, port = authority[:i], authority[i+1:]
+	}
+	if port == "*" {
+		// The default port is left out of urls, so a wildcard port may be empty.
+		port = "(*)"
+	}
+	if port == defaultPorts[strings.ToLower(scheme)] {
+		port = ""
+	}
+
+	query, err := parseQueryPattern(pattern, rawQuery)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var components [urlComponents][]pathVariant
+	for i, text := range []string{strings.ToLower(scheme), host, port, path} {
+		variants, err := parsePattern(text)
+		if err != nil {
+			return nil, nil, err
+		}
+		if urlComponent(i) == hostComponent {
+			for j, v := range variants {
+				variants[j] = reverseHostVariant(v)
+			}
+		}
+		components[i] = variants
+	}
+
+	var routes []urlRoute[T]
+	var variants []pathVariant
+	boundary := pathVariant{tokens: []pathToken{{kind: staticKind, text: urlBoundary}}}
+	for _, sv := range components[schemeComponent] {
+		for _, hv := range components[hostComponent] {
+			for _, pv := range components[portComponent] {
+				for _, v := range components[pathComponent] {
+					route := urlRoute[T]{pattern: pattern, query: query}
+					variant := pathVariant{}
+					for i, c := range []pathVariant{sv, hv, pv, v} {
+						if i > 0 {
+							variant = variant.concat(boundary)
+						}
+						variant = variant.concat(c)
+
+						for _, name := range c.paramNames() {
+							route.slots = append(route.slots, urlComponent(i))
+							route.names = append(route.names, name)
+						}
+						route.defaults[i] = c.defaults
+					}
+
+					routes = append(routes, route)
+					variants = append(variants, variant)
+				}
+			}
+		}
+	}
+	return routes, variants, nil
+}
+
+func (r *urlRoute[T]) params(values []string) URLParams {
+	var components [urlComponents]map[string]string
+	for i := range components {
+		components[i] = map[string]string{}
+		for name, v := range r.defaults[i] {
+			components[i][name] = v
+		}
+	}
+	for i, v := range values {
+		if r.slots[i] == hostComponent {
+			v = strings.Join(reverseLabels(strings.Split(v, "/")), ".")
+		}
+		components[r.slots[i]][r.names[i]] = v
+	}
+
+	return URLParams{
+		Scheme: components[schemeComponent],
+		Host:   components[hostComponent],
+		Port:   components[portComponent],
+		Path:   components[pathComponent],
+	}
+}
+
+func (r *urlRoute[T]) matchQuery(query url.Values) (map[string]string, bool) {
+	params := map[string]string{}
+	for _, q := range r.query {
+		matched := false
+		for _, v := range query[q.key] {
+			ok, captured := q.value.Match(v)
+			if !ok {
+				continue
+			}
+			for name, value := range captured {
+				// An unnamed wildcard captures under the key of the query.
+				if name == string(anyLabel) {
+					name = q.key
+				}
+				params[name] = value
+			}
+			matched = true
+			break
+		}
+		if !matched {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+func (r *urlRoute[T]) querySignature() string {
+	var sb strings.Builder
+	for _, q := range r.query {
+		sb.WriteString(q.key)
+		sb.WriteByte('=')
+		sb.WriteString(q.value.String())
+		sb.WriteByte('&')
+	}
+	return sb.String()
+}
+
+func parseQueryPattern(pattern string, rawQuery string) ([]urlQuery, error) {
+	if rawQuery == "" {
+		return nil, nil
+	}
+
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q has an invalid query: %s", ErrInvalidPattern, pattern, err)
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	query := make([]urlQuery, 0, len(keys))
+	for _, key := range keys {
+		if len(values[key]) > 1 {
+			return nil, fmt.Errorf("%w: %q has the query key %q more than once", ErrInvalidPattern, pattern, key)
+		}
+		value, err := CompilePattern(queryValuePattern(values[key][0]))
+		if err != nil {
+			return nil, err
+		}
+		query = append(query, urlQuery{key: key, value: value})
+	}
+	return query, nil
+}
+
+// queryValuePattern escapes what is literal in a query value before it is compiled. Only * and a :name starting the
+// value or following a character that can not be part of a name are patterns, so http://x and 12:30 stay literal.
+func queryValuePattern(value string) string {
+	var sb strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case c == '\\' && i+1 < len(value):
+			sb.WriteString(value[i : i+2])
+			i++
+		case c == paramLabel && (i == 0 || !isParamNameChar(value[i-1])) && i+1 < len(value) && isParamNameChar(value[i+1]):
+			// The name and the constraint of the param are kept as they are.
+			j := i + 1
+			for j < len(value) && isParamNameChar(value[j]) {
+				j++
+			}
+			if j < len(value) && value[j] == '<' {
+				if end, err := scanConstraint(value, j); err == nil {
+					j = end + 1
+				}
+			}
+			sb.WriteString(value[i:j])
+			i = j - 1
+		case c != anyLabel && isPatternLabel(c):
+			sb.WriteByte('\\')
+			sb.WriteByte(c)
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
+func isPortPattern(port string) bool {
+	if port == "*" {
+		return true
+	}
+	for i := 0; i < len(port); i++ {
+		if port[i] < '0' || port[i] > '9' {
+			return false
+		}
+	}
+	return port != ""
+}
+
+func indexOutsideConstraint(s string, chars string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\':
+			i++
+		case c == '[' && depth > 0:
+			if end := scanCharClass(s, i); end >= 0 {
+				i = end
+			}
+		case c == '<':
+			depth++
+		case c == '>':
+			depth--
+		case depth == 0 && strings.IndexByte(chars, c) >= 0:
+			return i
+		}
+	}
+	return -1
+}
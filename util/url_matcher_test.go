@@ -0,0 +1,199 @@
+package util
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestURLMatcher_Match(t *testing.T) {
+	m := NewURLMatcher[string]()
+
+	m.Add("https://:tenant.example.com:*/hooks/:id?token=*", "hook")
+	m.Add("https://:tenant.example.com:*/hooks/:id?token=*&mode=:mode", "hook mode")
+	m.Add("https://api.example.com/users/:id", "user")
+	m.Add("http(s)://*.cdn.example.com/*", "cdn")
+	m.Add(":scheme://example.com:8080/", "root")
+
+	testCases := []struct {
+		whenURL       string
+		expectValue   string
+		expectPattern string
+		expectParams  URLParams
+	}{
+		{
+			whenURL:       "https://acme.example.com:8443/hooks/1?token=abc",
+			expectValue:   "hook",
+			expectPattern: "https://:tenant.example.com:*/hooks/:id?token=*",
+			expectParams: URLParams{
+				Scheme: map[string]string{},
+				Host:   map[string]string{"tenant": "acme"},
+				Port:   map[string]string{"*": "8443"},
+				Path:   map[string]string{"id": "1"},
+				Query:  map[string]string{"token": "abc"},
+			},
+		},
+		{
+			whenURL:       "https://acme.example.com/hooks/1?token=abc&mode=test",
+			expectValue:   "hook mode",
+			expectPattern: "https://:tenant.example.com:*/hooks/:id?token=*&mode=:mode",
+			expectParams: URLParams{
+				Scheme: map[string]string{},
+				Host:   map[string]string{"tenant": "acme"},
+				Port:   map[string]string{},
+				Path:   map[string]string{"id": "1"},
+				Query:  map[string]string{"token": "abc", "mode": "test"},
+			},
+		},
+		{
+			whenURL:       "HTTPS://API.example.com:443/users/1",
+			expectValue:   "user",
+			expectPattern: "https://api.example.com/users/:id",
+			expectParams: URLParams{
+				Scheme: map[string]string{},
+				Host:   map[string]string{},
+				Port:   map[string]string{},
+				Path:   map[string]string{"id": "1"},
+				Query:  map[string]string{},
+			},
+		},
+		{
+			whenURL:       "http://a.b.cdn.example.com/x/y.js",
+			expectValue:   "cdn",
+			expectPattern: "http(s)://*.cdn.example.com/*",
+			expectParams: URLParams{
+				Scheme: map[string]string{},
+				Host:   map[string]string{"*": "a.b"},
+				Port:   map[string]string{},
+				Path:   map[string]string{"*": "x/y.js"},
+				Query:  map[string]string{},
+			},
+		},
+		{
+			whenURL:       "ftp://example.com:8080",
+			expectValue:   "root",
+			expectPattern: ":scheme://example.com:8080/",
+			expectParams: URLParams{
+				Scheme: map[string]string{"scheme": "ftp"},
+				Host:   map[string]string{},
+				Port:   map[string]string{},
+				Path:   map[string]string{},
+				Query:  map[string]string{},
+			},
+		},
+		{
+			whenURL:       "https://acme.example.com/hooks/1",
+			expectValue:   "",
+			expectPattern: "",
+			expectParams:  URLParams{},
+		},
+		{
+			whenURL:       "https://api.example.com:8443/users/1",
+			expectValue:   "",
+			expectPattern: "",
+			expectParams:  URLParams{},
+		},
+		{
+			whenURL:       "http://example.com/users/1",
+			expectValue:   "",
+			expectPattern: "",
+			expectParams:  URLParams{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.whenURL, func(t *testing.T) {
+			value, pattern, params := m.Match(tc.whenURL)
+			assert.Equal(t, tc.expectValue, value)
+			assert.Equal(t, tc.expectPattern, pattern)
+			assert.Equal(t, tc.expectParams, params)
+		})
+	}
+}
+
+func TestURLMatcher_AddE(t *testing.T) {
+	m := NewURLMatcher[string]()
+
+	assert.NoError(t, m.AddE("https://example.com/a?x=1", "a"))
+	assert.NoError(t, m.AddE("https://example.com/a", "b"))
+	assert.ErrorIs(t, m.AddE("https://example.com/a?x=1", "c"), ErrRouteConflict)
+	assert.ErrorIs(t, m.AddE("example.com/a", "d"), ErrInvalidPattern)
+	assert.ErrorIs(t, m.AddE("https://example.com/a?x=1&x=2", "e"), ErrInvalidPattern)
+
+	assert.True(t, m.Remove("https://example.com/a?x=1"))
+	assert.False(t, m.Remove("https://example.com/a?x=1"))
+
+	value, _, _ := m.Match("https://example.com/a?x=1")
+	assert.Equal(t, "b", value)
+}
+
+func TestURLMatcher_AddAgain(t *testing.T) {
+	m := NewURLMatcher[int]()
+
+	m.Add("https://example.com/a?x=*", 1)
+	m.Add("https://example.com/a", 3)
+	m.Add("https://example.com/a?x=*", 2)
+
+	value, pattern, _ := m.Match("https://example.com/a?x=1")
+	assert.Equal(t, 2, value)
+	assert.Equal(t, "https://example.com/a?x=*", pattern)
+
+	assert.True(t, m.Remove("https://example.com/a?x=*"))
+	assert.False(t, m.Remove("https://example.com/a?x=*"))
+
+	value, _, _ = m.Match("https://example.com/a?x=1")
+	assert.Equal(t, 3, value)
+}
+
+func TestURLMatcher_QueryLiteral(t *testing.T) {
+	m := NewURLMatcher[string]()
+
+	assert.NoError(t, m.AddE("https://example.com/cb?next=http://x", "next"))
+	assert.NoError(t, m.AddE("https://example.com/at?time=12:30&zone=:zone", "time"))
+	assert.NoError(t, m.AddE("https://example.com/q?q=(a)", "group"))
+
+	testCases := []struct {
+		whenURL      string
+		expectValue  string
+		expectParams map[string]string
+	}{
+		{
+			whenURL:      "https://example.com/cb?next=http%3A%2F%2Fx",
+			expectValue:  "next",
+			expectParams: map[string]string{},
+		},
+		{
+			whenURL: "https://example.com/cb?next=http%3A%2F%2Fy",
+		},
+		{
+			whenURL:      "https://example.com/at?time=12:30&zone=utc",
+			expectValue:  "time",
+			expectParams: map[string]string{"zone": "utc"},
+		},
+		{
+			whenURL: "https://example.com/at?time=12:31&zone=utc",
+		},
+		{
+			whenURL:      "https://example.com/q?q=(a)",
+			expectValue:  "group",
+			expectParams: map[string]string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		value, _, params := m.Match(tc.whenURL)
+		assert.Equal(t, tc.expectValue, value, tc.whenURL)
+		if tc.expectParams != nil {
+			assert.Equal(t, tc.expectParams, params.Query, tc.whenURL)
+		}
+	}
+}
+
+func TestURLMatcher_ConstraintClass(t *testing.T) {
+	m := NewURLMatcher[string]()
+	assert.NoError(t, m.AddE("https://example.com/tags/:tag<[^>?/]+>?x=*", "tag"))
+
+	value, _, params := m.Match("https://example.com/tags/a-b?x=1")
+	assert.Equal(t, "tag", value)
+	assert.Equal(t, map[string]string{"tag": "a-b"}, params.Path)
+	assert.Equal(t, map[string]string{"x": "1"}, params.Query)
+}